@@ -18,17 +18,33 @@ import (
 	"go/build"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/olekukonko/tablewriter"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/github"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/printer"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/repository"
 )
 
 const (
 	ackdevConfigFileName = ".ackdev.yaml"
+	// ackdevHomeDirectoryName is the name of the directory containing the files
+	// managed by ackdev (tools binaries, logs...). It can be overridden using the
+	// ACKDEV_HOME environment variable.
+	ackdevHomeDirectoryName = ".ackdev"
+	ackdevHomeEnvVar        = "ACKDEV_HOME"
+	// defaultStatusWorkers is the number of repositories inspected concurrently
+	// when a filter expression needs the repositories status.
+	defaultStatusWorkers = 4
 )
 
 var (
 	homeDirectory        string
+	ackdevHomeDirectory  string
 	defaultConfigPath    string
 	goPath               = build.Default.GOPATH
 	defaultRootDirectory = filepath.Join(goPath, "src/github.com/aws-controllers-k8s")
@@ -43,22 +59,184 @@ func init() {
 	}
 	homeDirectory = hd
 	defaultConfigPath = filepath.Join(homeDirectory, ackdevConfigFileName)
+
+	ackdevHomeDirectory = os.Getenv(ackdevHomeEnvVar)
+	if ackdevHomeDirectory == "" {
+		ackdevHomeDirectory = filepath.Join(homeDirectory, ackdevHomeDirectoryName)
+	}
+}
+
+// ackdevBinDirectory returns the directory containing the tools installed
+// by ackdev.
+func ackdevBinDirectory() string {
+	return filepath.Join(ackdevHomeDirectory, "bin")
+}
+
+// ackdevLogsDirectory returns the directory containing the logs of the
+// commands executed by ackdev.
+func ackdevLogsDirectory() string {
+	return filepath.Join(ackdevHomeDirectory, "logs")
+}
+
+// ackdevGithubCacheDirectory returns the directory caching the Github API
+// responses.
+func ackdevGithubCacheDirectory() string {
+	return filepath.Join(ackdevHomeDirectory, "cache", "github")
+}
+
+// newGithubClient returns a Github client authenticated with the configured
+// token, using the configured endpoints and caching its responses in the
+// ackdev home.
+func newGithubClient(cfg *config.Config) (*github.Client, error) {
+	return github.NewClientFromConfig(&cfg.Github, ackdevGithubCacheDirectory())
+}
+
+// colorEnabled returns true if f is a terminal and colors are not disabled
+// using the NO_COLOR environment variable.
+func colorEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// prependBinDirectoryToPath adds the ackdev bin directory in front of the
+// PATH environment variable, so that the installed tools are found by ackdev
+// and by the commands it executes.
+func prependBinDirectoryToPath() error {
+	return os.Setenv("PATH", ackdevBinDirectory()+string(os.PathListSeparator)+os.Getenv("PATH"))
 }
 
 func newTable() *tablewriter.Table {
-	table := tablewriter.NewWriter(os.Stdout)
-
-	// Kubectl tables like style
-	table.SetAutoWrapText(false)
-	table.SetAutoFormatHeaders(true)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetCenterSeparator("")
-	table.SetColumnSeparator("")
-	table.SetRowSeparator("")
-	table.SetHeaderLine(false)
-	table.SetBorder(false)
-	table.SetTablePadding(" ")
-	table.SetNoWhiteSpace(true)
-	return table
+	return printer.NewTable(os.Stdout)
+}
+
+// formatHead returns a human readable description of a repository HEAD,
+// similar to what 'git status' displays.
+func formatHead(repo *repository.Repository) string {
+	switch repo.State {
+	case repository.RepositoryStateBranch:
+		return repo.GitHead
+	case repository.RepositoryStateDetached:
+		return fmt.Sprintf("(detached at %s)", repo.GitHead)
+	case repository.RepositoryStateEmpty:
+		return fmt.Sprintf("%s (no commits)", repo.GitHead)
+	default:
+		return "(not cloned)"
+	}
+}
+
+// humanDuration returns a short human readable representation of a duration,
+// using the largest relevant unit. For example: 45s, 12m, 5h, 3d, 2y.
+func humanDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 2*365*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/24/365))
+	}
+}
+
+// loadRepositoryManager reads ackdev configuration and returns a repository
+// manager with all the configured repositories loaded.
+func loadRepositoryManager() (*repository.Manager, error) {
+	cfg, err := config.Load(ackConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return newRepositoryManager(cfg)
+}
+
+// newRepositoryManager returns a repository manager with all the repositories
+// of the given configuration loaded.
+func newRepositoryManager(cfg *config.Config) (*repository.Manager, error) {
+	ghc, err := newGithubClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	repoManager, err := repository.NewManager(cfg, repository.WithGithubClient(ghc))
+	if err != nil {
+		return nil, err
+	}
+
+	// Try to load all repositories
+	err = repoManager.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+	return repoManager, nil
+}
+
+// selectRepositories returns the repositories targeted by a command. Repositories
+// can be selected by name, or all at once when all is true. The filter expression
+// is applied on top of the selection. When no names are given and all is false, a
+// non empty filter expression is required.
+func selectRepositories(
+	repoManager *repository.Manager,
+	names []string,
+	all bool,
+	filterExpression string,
+) ([]*repository.Repository, error) {
+	if len(names) > 0 && all {
+		return nil, fmt.Errorf("cannot specify repository names with --all")
+	}
+	if len(names) == 0 && !all && strings.TrimSpace(filterExpression) == "" {
+		return nil, fmt.Errorf("specify at least one repository name, --all or --filter")
+	}
+
+	expr, err := repository.ParseFilterExpression(filterExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := repoManager.List()
+	if len(names) > 0 {
+		candidates = make([]*repository.Repository, 0, len(names))
+		for _, name := range names {
+			repo, err := repoManager.GetRepository(name)
+			if err != nil {
+				return nil, fmt.Errorf("unknown repository %s: %v", name, err)
+			}
+			candidates = append(candidates, repo)
+		}
+	}
+	return filterRepositories(repoManager, candidates, expr)
+}
+
+// filterRepositories returns the repositories matching a filter expression.
+// If the expression needs the repositories status, it is loaded first.
+func filterRepositories(
+	repoManager *repository.Manager,
+	repos []*repository.Repository,
+	expr *repository.FilterExpression,
+) ([]*repository.Repository, error) {
+	if expr.NeedsStatus() {
+		err := repoManager.LoadStatus(defaultStatusWorkers, repos...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filtered := []*repository.Repository{}
+	filters := expr.Filters()
+mainLoop:
+	for _, repo := range repos {
+		for _, filter := range filters {
+			if !filter(repo) {
+				continue mainLoop
+			}
+		}
+		filtered = append(filtered, repo)
+	}
+	return filtered, nil
 }
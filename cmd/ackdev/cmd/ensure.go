@@ -0,0 +1,94 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/repository"
+)
+
+var (
+	ensureTableHeaderColumns = []string{"Name", "Type", "Result", "Error"}
+
+	optEnsureAll              bool
+	optEnsureFilterExpression string
+	optEnsureWorkers          int
+)
+
+func init() {
+	ensureCmd.PersistentFlags().BoolVar(&optEnsureAll, "all", false, "ensure all the configured repositories")
+	ensureCmd.PersistentFlags().StringVarP(&optEnsureFilterExpression, "filter", "f", "", "filter expression")
+	ensureCmd.PersistentFlags().IntVarP(&optEnsureWorkers, "workers", "j", 4, "number of repositories ensured concurrently")
+}
+
+var ensureCmd = &cobra.Command{
+	Use:   "ensure [repo...]",
+	Short: "Fork and clone ACK repositories",
+	Long: `Ensure that the selected repositories are forked to your Github account,
+renamed to follow the configured fork prefix and cloned in the root directory.`,
+	Example: "ackdev ensure s3 runtime\nackdev ensure --all\nackdev ensure -f type=controller",
+	RunE:    ensureRepositories,
+}
+
+func ensureRepositories(cmd *cobra.Command, args []string) error {
+	repoManager, err := loadRepositoryManager()
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(repoManager, args, optEnsureAll, optEnsureFilterExpression)
+	if err != nil {
+		return err
+	}
+
+	results := repoManager.Ensure(context.Background(), optEnsureWorkers, repos...)
+	tablePrintEnsureResults(results)
+
+	// errors are already displayed in the results table, only report
+	// the number of failures.
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to ensure %d/%d repositories", failed, len(results))
+	}
+	return nil
+}
+
+func tablePrintEnsureResults(results []*repository.EnsureResult) {
+	tw := newTable()
+	defer tw.Render()
+
+	tw.SetHeader(ensureTableHeaderColumns)
+
+	for _, res := range results {
+		errMsg := ""
+		if res.Err != nil {
+			errMsg = res.Err.Error()
+		}
+		tw.Append([]string{
+			res.Repository.Name,
+			res.Repository.Type.String(),
+			res.Status(),
+			errMsg,
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/asyncexec"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/repository"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/util"
+)
+
+// execResult is the status of a command executed in a repository
+type execResult string
+
+const (
+	execResultOK      execResult = "OK"
+	execResultFailed  execResult = "FAILED"
+	execResultAborted execResult = "ABORTED"
+	execResultSkipped execResult = "SKIPPED"
+
+	// execOutputBuffer is the number of output lines buffered per command
+	execOutputBuffer = 64
+)
+
+var (
+	execTableHeaderColumns = []string{"Name", "Result", "Exit Code", "Duration", "Error"}
+
+	optExecAll              bool
+	optExecFilterExpression string
+	optExecWorkers          int
+	optExecFailFast         bool
+	optExecLog              bool
+	optExecNoColor          bool
+)
+
+func init() {
+	execCmd.PersistentFlags().BoolVar(&optExecAll, "all", false, "execute the command in all the configured repositories")
+	execCmd.PersistentFlags().StringVarP(&optExecFilterExpression, "filter", "f", "", "filter expression")
+	execCmd.PersistentFlags().IntVarP(&optExecWorkers, "workers", "j", 4, "number of commands executed concurrently")
+	execCmd.PersistentFlags().BoolVar(&optExecFailFast, "fail-fast", false, "abort the remaining commands when one fails")
+	execCmd.PersistentFlags().BoolVar(&optExecLog, "log", false, "write the output of each command to a log file in the ackdev logs directory")
+	execCmd.PersistentFlags().BoolVar(&optExecNoColor, "no-color", false, "disable colored output")
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [repo...] -- command [args...]",
+	Short: "Execute a command in ACK repositories",
+	Long: `Execute a command in the local directory of each selected repository. Commands
+run concurrently, their output is prefixed with the repository name. Repositories
+that are not cloned are skipped and listed after the summary of the exit codes
+and durations, printed once all the commands are done. The command fails when
+no repository could be used.`,
+	Example: "ackdev exec s3 runtime -- git status --short\nackdev exec -f type=controller -- make test\nackdev exec --all --fail-fast -- sh -c 'go mod tidy && git diff --exit-code'",
+	RunE:    execCommand,
+}
+
+// execRecord is the result of a command executed in a repository
+type execRecord struct {
+	repository *repository.Repository
+	result     execResult
+	exitCode   int
+	duration   time.Duration
+	err        error
+}
+
+func execCommand(cmd *cobra.Command, args []string) error {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 || dash == len(args) {
+		return fmt.Errorf("a command is required after --")
+	}
+	names, command := args[:dash], args[dash:]
+
+	repoManager, err := loadRepositoryManager()
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(repoManager, names, optExecAll, optExecFilterExpression)
+	if err != nil {
+		return err
+	}
+
+	var muxOpts []asyncexec.MultiplexerOption
+	muxOpts = append(muxOpts, asyncexec.WithColor(!optExecNoColor && colorEnabled(os.Stdout)))
+	if optExecLog {
+		muxOpts = append(muxOpts, asyncexec.WithLogDirectory(filepath.Join(ackdevLogsDirectory(), "exec")))
+	}
+	mux := asyncexec.NewMultiplexer(os.Stdout, muxOpts...)
+
+	// stop the running commands on SIGINT and SIGTERM
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	records, err := executeInRepositories(ctx, cancel, mux, repos, command)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	tablePrintExecRecords(records)
+
+	return execSummaryError(records)
+}
+
+// execSummaryError returns an error reporting the number of failed, aborted
+// and skipped commands. Errors are already displayed in the records table,
+// only the counts are reported. Skipped repositories alone are not an error
+// unless no command was executed at all.
+func execSummaryError(records []*execRecord) error {
+	failed, aborted := 0, 0
+	var skipped []string
+	for _, rec := range records {
+		switch rec.result {
+		case execResultFailed:
+			failed++
+		case execResultAborted:
+			aborted++
+		case execResultSkipped:
+			skipped = append(skipped, rec.repository.Name)
+		}
+	}
+
+	var counts []string
+	if failed > 0 || aborted > 0 {
+		counts = append(counts, fmt.Sprintf("%d/%d commands failed (%d aborted)", failed+aborted, len(records), aborted))
+	}
+	if len(skipped) > 0 && (len(counts) > 0 || len(skipped) == len(records)) {
+		counts = append(counts, fmt.Sprintf("%d/%d repositories skipped (%s)",
+			len(skipped), len(records), strings.Join(skipped, ", ")))
+	}
+	if len(counts) == 0 {
+		if len(skipped) > 0 {
+			fmt.Printf("%d/%d repositories skipped (%s)\n",
+				len(skipped), len(records), strings.Join(skipped, ", "))
+		}
+		return nil
+	}
+	return errors.New(strings.Join(counts, ", "))
+}
+
+// executeInRepositories executes a command in the given repositories using a
+// pool of workers. When --fail-fast is set, the first failure cancels the
+// remaining commands.
+func executeInRepositories(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	mux *asyncexec.Multiplexer,
+	repos []*repository.Repository,
+	command []string,
+) ([]*execRecord, error) {
+	records := make([]*execRecord, len(repos))
+	// create all the jobs upfront to align the output prefixes
+	jobs := make([]*asyncexec.Job, len(repos))
+	for i, repo := range repos {
+		job, err := mux.Job(repo.Name)
+		if err != nil {
+			return nil, err
+		}
+		jobs[i] = job
+	}
+
+	util.Parallelize(optExecWorkers, len(repos), func(i int) {
+		rec := executeInRepository(ctx, jobs[i], repos[i], command)
+		if rec.result == execResultFailed && optExecFailFast {
+			cancel()
+		}
+		records[i] = rec
+	})
+	return records, nil
+}
+
+// executeInRepository executes a command in a repository directory and
+// streams its output through job.
+func executeInRepository(
+	ctx context.Context,
+	job *asyncexec.Job,
+	repo *repository.Repository,
+	command []string,
+) *execRecord {
+	defer job.Close()
+	rec := &execRecord{repository: repo, exitCode: -1}
+
+	if repo.State == repository.RepositoryStateNotCloned {
+		rec.result = execResultSkipped
+		rec.err = fmt.Errorf("repository is not cloned")
+		return rec
+	}
+	if ctx.Err() != nil {
+		rec.result = execResultAborted
+		return rec
+	}
+
+	c := exec.Command(command[0], command[1:]...)
+	c.Dir = repo.FullPath
+	acmd := asyncexec.NewWithContext(ctx, c, execOutputBuffer)
+
+	start := time.Now()
+	if err := acmd.Run(); err != nil {
+		rec.result = execResultFailed
+		rec.err = err
+		return rec
+	}
+	if err := job.Stream(acmd); err != nil {
+		acmd.Stop()
+		rec.err = err
+	}
+	err := acmd.Wait()
+	rec.duration = time.Since(start)
+	rec.exitCode = acmd.ExitCode()
+
+	switch {
+	// the command was stopped by the cancellation, commands which failed
+	// before are reported as failed
+	case err != nil && err == ctx.Err():
+		rec.result = execResultAborted
+	case err != nil:
+		rec.result = execResultFailed
+		if rec.err == nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				rec.err = err
+			}
+		}
+	case rec.err != nil:
+		rec.result = execResultFailed
+	default:
+		rec.result = execResultOK
+	}
+	return rec
+}
+
+func tablePrintExecRecords(records []*execRecord) {
+	tw := newTable()
+	defer tw.Render()
+
+	tw.SetHeader(execTableHeaderColumns)
+
+	for _, rec := range records {
+		exitCode := ""
+		if rec.exitCode >= 0 {
+			exitCode = strconv.Itoa(rec.exitCode)
+		}
+		duration := ""
+		if rec.duration > 0 {
+			duration = rec.duration.Round(10 * time.Millisecond).String()
+		}
+		errMsg := ""
+		if rec.err != nil {
+			errMsg = rec.err.Error()
+		}
+		tw.Append([]string{
+			rec.repository.Name,
+			string(rec.result),
+			exitCode,
+			duration,
+			errMsg,
+		})
+	}
+}
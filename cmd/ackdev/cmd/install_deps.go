@@ -0,0 +1,177 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/deps"
+)
+
+var (
+	installDepsTableHeaderColumns = []string{"Name", "Version", "Result", "Path", "Error"}
+)
+
+func init() {
+	installDependenciesCmd.PersistentFlags().DurationVar(&optDepsProbeTimeout, "timeout", deps.DefaultProbeTimeout, "maximum duration of each dependency version command")
+	installDependenciesCmd.PersistentFlags().StringVar(&optDepsListToolsFile, "tools-file", "", "file declaring the tools version constraints (default: the first "+config.ToolsFileName+" found in the current directory or its parents)")
+}
+
+var installDependenciesCmd = &cobra.Command{
+	Use:     "dependency [name[@version]...]",
+	Aliases: []string{"dep", "deps", "dependencies"},
+	Short:   "Download and install ACK development dependencies",
+	Long: `Download the release binaries of the given dependencies, verify their SHA256
+checksum and install them in the ackdev bin directory ($ACKDEV_HOME/bin). When
+no dependency is given, all the dependencies that are missing or not compliant
+with their version constraint are installed. Dependencies that don't publish
+release binaries, such as go, are reported and have to be installed manually.
+
+The installed version is the one pinned by the dependency version constraint,
+or a default version. It can also be given explicitly using name@version.`,
+	Example: "ackdev install deps\nackdev install deps kind helm@3.5.4",
+	RunE:    installDependencies,
+}
+
+// installResult is the result of a dependency installation
+type installResult struct {
+	name    string
+	version string
+	path    string
+	err     error
+	// manual is true for dependencies without a downloadable release, they
+	// have to be installed manually.
+	manual bool
+}
+
+func installDependencies(cmd *cobra.Command, args []string) error {
+	dependencies, constraints, err := loadDependencies()
+	if err != nil {
+		return err
+	}
+
+	type installRequest struct {
+		dep     deps.Dependency
+		version string
+	}
+	var requests []installRequest
+	// manual are the non compliant dependencies that can't be downloaded
+	var manual []*installResult
+	if len(args) == 0 {
+		results := deps.CheckAll(context.Background(), dependencies, constraints, optDepsProbeTimeout)
+		for _, result := range results {
+			if result.Compliant() {
+				continue
+			}
+			if result.Dependency.Release == nil {
+				manual = append(manual, &installResult{name: result.Dependency.BinaryName, manual: true})
+				continue
+			}
+			requests = append(requests, installRequest{dep: result.Dependency})
+		}
+		if len(requests) == 0 && len(manual) == 0 {
+			fmt.Println("All dependencies are compliant, nothing to install.")
+			return nil
+		}
+	}
+	for _, arg := range args {
+		name, version := arg, ""
+		if i := strings.IndexByte(arg, '@'); i >= 0 {
+			name, version = arg[:i], arg[i+1:]
+		}
+		dep, ok := findDependency(dependencies, name)
+		if !ok {
+			return fmt.Errorf("unknown dependency %s", name)
+		}
+		requests = append(requests, installRequest{dep: dep, version: version})
+	}
+
+	installer := deps.NewInstaller(ackdevBinDirectory())
+	results := make([]*installResult, 0, len(requests)+len(manual))
+	failed := 0
+	for _, req := range requests {
+		res := installDependency(installer, req.dep, req.version, constraints[req.dep.BinaryName])
+		if res.err != nil {
+			failed++
+		}
+		results = append(results, res)
+	}
+	results = append(results, manual...)
+	tablePrintInstallResults(results)
+
+	if failed > 0 {
+		return fmt.Errorf("failed to install %d/%d dependencies", failed, len(requests))
+	}
+	return nil
+}
+
+// installDependency installs a dependency. If version is empty it is
+// resolved using the dependency constraint.
+func installDependency(
+	installer *deps.Installer,
+	dep deps.Dependency,
+	version string,
+	constraint *deps.Constraint,
+) *installResult {
+	res := &installResult{name: dep.BinaryName, version: version}
+
+	var v deps.Version
+	var err error
+	if version != "" {
+		v, err = deps.ParseVersion(version)
+	} else {
+		v, err = deps.ResolveVersion(dep, constraint)
+	}
+	if err != nil {
+		res.err = err
+		return res
+	}
+	res.version = v.String()
+
+	res.path, res.err = installer.Install(context.Background(), dep, v)
+	return res
+}
+
+// findDependency returns the dependency with the given name
+func findDependency(dependencies []deps.Dependency, name string) (deps.Dependency, bool) {
+	for _, dep := range dependencies {
+		if dep.BinaryName == name {
+			return dep, true
+		}
+	}
+	return deps.Dependency{}, false
+}
+
+func tablePrintInstallResults(results []*installResult) {
+	tw := newTable()
+	defer tw.Render()
+
+	tw.SetHeader(installDepsTableHeaderColumns)
+
+	for _, res := range results {
+		status, errMsg := "installed", ""
+		switch {
+		case res.manual:
+			status, errMsg = "not installable", "install manually"
+		case res.err != nil:
+			status, errMsg = "failed", res.err.Error()
+		}
+		tw.Append([]string{res.name, res.version, status, res.path, errMsg})
+	}
+}
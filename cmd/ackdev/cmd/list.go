@@ -13,7 +13,11 @@
 
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/printer"
+)
 
 var (
 	optListOutputFormat string
@@ -23,8 +27,9 @@ func init() {
 	listCmd.AddCommand(listDependenciesCmd)
 	listCmd.AddCommand(listRepositoriesCmd)
 	listCmd.AddCommand(getConfigCmd)
+	listCmd.AddCommand(listRunConfigCmd)
 
-	getConfigCmd.PersistentFlags().StringVarP(&optListOutputFormat, "output", "o", "yaml", "output format (json|yaml)")
+	listCmd.PersistentFlags().StringVarP(&optListOutputFormat, "output", "o", "", "output format ("+printer.SupportedFormats+")")
 }
 
 var listCmd = &cobra.Command{
@@ -33,3 +38,13 @@ var listCmd = &cobra.Command{
 	Args:    cobra.NoArgs,
 	Short:   "Display one or many resources",
 }
+
+// newListPrinter returns a printer for the --output flag value. defaultFormat
+// is used when the flag isn't set.
+func newListPrinter(defaultFormat printer.Format) (*printer.Printer, error) {
+	output := optListOutputFormat
+	if output == "" {
+		output = string(defaultFormat)
+	}
+	return printer.New(output)
+}
@@ -16,11 +16,12 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
-	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 
 	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/printer"
 )
 
 var getConfigCmd = &cobra.Command{
@@ -31,27 +32,24 @@ var getConfigCmd = &cobra.Command{
 }
 
 func printConfig(*cobra.Command, []string) error {
+	p, err := newListPrinter(printer.FormatYAML)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := config.Load(ackConfigPath)
 	if err != nil {
 		return err
 	}
 
-	var b []byte
-	switch optListOutputFormat {
-	case "json":
-		b, err = json.Marshal(cfg)
+	// the configuration is printed as compact JSON, on a single line
+	if p.Format() == printer.FormatJSON {
+		b, err := json.Marshal(cfg)
 		if err != nil {
 			return err
 		}
-	case "yaml":
-		b, err = yaml.Marshal(cfg)
-		if err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("unsupported output type: %s", optListOutputFormat)
+		fmt.Println(string(b))
+		return nil
 	}
-
-	fmt.Println(string(b))
-	return nil
+	return p.Print(os.Stdout, cfg)
 }
@@ -14,20 +14,32 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
 	"github.com/aws-controllers-k8s/dev-tools/pkg/deps"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/printer"
 )
 
 var (
 	listDepsTableHeaderColumns = []string{"Name", "Status"}
 	optDepsListShowPath        bool
 	optDepsListShowVersion     bool
+	optDepsListToolsFile       string
+	optDepsProbeTimeout        time.Duration
 )
 
 func init() {
 	listDependenciesCmd.PersistentFlags().BoolVar(&optDepsListShowPath, "show-path", true, "display binary path")
 	listDependenciesCmd.PersistentFlags().BoolVar(&optDepsListShowVersion, "show-version", true, "display binary version")
+	listDependenciesCmd.PersistentFlags().DurationVar(&optDepsProbeTimeout, "timeout", deps.DefaultProbeTimeout, "maximum duration of each dependency version command")
+	listDependenciesCmd.PersistentFlags().StringVar(&optDepsListToolsFile, "tools-file", "", "file declaring the tools version constraints (default: the first "+config.ToolsFileName+" found in the current directory or its parents)")
 }
 
 var listDependenciesCmd = &cobra.Command{
@@ -37,80 +49,174 @@ var listDependenciesCmd = &cobra.Command{
 }
 
 type depRecord struct {
-	Name    string
-	Version string
-	Path    string
-	Status  string
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Path       string `json:"path"`
+	Constraint string `json:"constraint,omitempty"`
+	Status     string `json:"status"`
+	Optional   bool   `json:"optional"`
+	Message    string `json:"message,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
 }
 
 func printDependencies(cmd *cobra.Command, args []string) error {
-	dependencies, err := listDependencies()
+	p, err := newListPrinter(printer.FormatTable)
+	if err != nil {
+		return err
+	}
+
+	dependencies, constraints, err := loadDependencies()
 	if err != nil {
 		return err
 	}
 
-	tablePrintDependencies(dependencies)
+	records, nonCompliant := listDependencies(dependencies, constraints)
+	if err := p.Print(os.Stdout, &dependencyList{Items: records}); err != nil {
+		return err
+	}
+	if nonCompliant > 0 {
+		return fmt.Errorf("%d/%d dependencies are not compliant", nonCompliant, len(records))
+	}
 	return nil
 }
 
-// tablePrintDependencies prints the ACK development dependencies in table
-func tablePrintDependencies(dependencies []*depRecord) error {
-	// table headers
-	tableHeaderColumns := listDepsTableHeaderColumns
-	if optDepsListShowVersion {
-		tableHeaderColumns = append(tableHeaderColumns, "Version")
+// loadDependencies returns the built-in dependencies merged with the ones
+// declared in the ackdev configuration, along with their version constraints
+// declared in the configuration and in the tools file. A missing
+// configuration file isn't an error.
+func loadDependencies() ([]deps.Dependency, map[string]*deps.Constraint, error) {
+	cfg, err := config.Load(ackConfigPath)
+	if os.IsNotExist(err) {
+		cfg, err = &config.Config{}, nil
 	}
-	if optDepsListShowPath {
-		tableHeaderColumns = append(tableHeaderColumns, "Path")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dependencies, err := deps.Merge(deps.DevelopmentTools, cfg.Dependencies)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	tw := newTable()
-	defer tw.Render()
+	toolsFile := optDepsListToolsFile
+	if toolsFile == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, nil, err
+		}
+		toolsFile, err = config.FindToolsFile(wd)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
-	// Add header
-	tw.SetHeader(tableHeaderColumns)
+	constraints, err := cfg.ToolConstraints(toolsFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsed, err := deps.ParseConstraints(dependencies, constraints)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dependencies, parsed, nil
+}
 
-	for _, tool := range dependencies {
-		rawArgs := []string{tool.Name, tool.Status}
-		if optDepsListShowVersion {
-			rawArgs = append(rawArgs, tool.Version)
+// dependencyList is the printable list of ACK development dependencies
+type dependencyList struct {
+	Items []*depRecord `json:"items"`
+}
+
+// Table implements printer.Tabular
+func (l *dependencyList) Table(wide bool) ([]string, [][]string) {
+	showConstraint, showMessage := wide, wide
+	for _, tool := range l.Items {
+		showConstraint = showConstraint || tool.Constraint != ""
+		showMessage = showMessage || tool.Message != ""
+	}
+
+	header := append([]string{}, listDepsTableHeaderColumns...)
+	if optDepsListShowVersion || wide {
+		header = append(header, "Version")
+	}
+	if showConstraint {
+		header = append(header, "Constraint")
+	}
+	if optDepsListShowPath || wide {
+		header = append(header, "Path")
+	}
+	if wide {
+		header = append(header, "Optional")
+	}
+	if showMessage {
+		header = append(header, "Message")
+	}
+
+	rows := make([][]string, 0, len(l.Items))
+	for _, tool := range l.Items {
+		row := []string{tool.Name, tool.Status}
+		if optDepsListShowVersion || wide {
+			row = append(row, tool.Version)
+		}
+		if showConstraint {
+			row = append(row, tool.Constraint)
 		}
-		if optDepsListShowPath {
-			rawArgs = append(rawArgs, tool.Path)
+		if optDepsListShowPath || wide {
+			row = append(row, tool.Path)
 		}
-		tw.Append(rawArgs)
+		if wide {
+			row = append(row, strconv.FormatBool(tool.Optional))
+		}
+		if showMessage {
+			row = append(row, tool.Message)
+		}
+		rows = append(rows, row)
 	}
+	return header, rows
+}
 
-	return nil
+// Names implements printer.Named
+func (l *dependencyList) Names() []string {
+	names := make([]string, 0, len(l.Items))
+	for _, tool := range l.Items {
+		names = append(names, tool.Name)
+	}
+	return names
 }
 
 // listDependencies returns the list of ACK development dependencies
-// along with their versions and binary paths.
-func listDependencies() ([]*depRecord, error) {
-	list := make([]*depRecord, 0, len(deps.DevelopmentTools))
-	for _, tool := range deps.DevelopmentTools {
-		status := ""
-		path, err := tool.BinPath()
-		if err != nil {
-			status = "NOT FOUND"
-		} else {
-			status = "OK"
+// along with their versions, binary paths and compliance status. It also
+// returns the number of dependencies that are not compliant.
+func listDependencies(
+	dependencies []deps.Dependency,
+	constraints map[string]*deps.Constraint,
+) ([]*depRecord, int) {
+	results := deps.CheckAll(context.Background(), dependencies, constraints, optDepsProbeTimeout)
+
+	list := make([]*depRecord, 0, len(results))
+	nonCompliant := 0
+	for _, result := range results {
+		if !result.Compliant() {
+			nonCompliant++
 		}
 
-		version := "-"
-		if status == "OK" {
-			v, err := tool.Version()
-			if err != nil && err != deps.ErrorVersionNotFound {
-				return nil, err
-			}
-			version = v
+		record := &depRecord{
+			Name:     result.Dependency.BinaryName,
+			Version:  result.Version,
+			Path:     result.Path,
+			Status:   string(result.Status),
+			Optional: result.Dependency.Optional,
+			Stderr:   result.Stderr,
+		}
+		if record.Version == "" {
+			record.Version = "-"
+		}
+		if result.Constraint != nil {
+			record.Constraint = result.Constraint.String()
 		}
-		list = append(list, &depRecord{
-			Name:    tool.BinaryName,
-			Version: version,
-			Path:    path,
-			Status:  status,
-		})
-	}
-	return list, nil
+		if result.Err != nil {
+			record.Message = result.Err.Error()
+		}
+		list = append(list, record)
+	}
+	return list, nonCompliant
 }
@@ -14,9 +14,16 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/printer"
 	"github.com/aws-controllers-k8s/dev-tools/pkg/repository"
 )
 
@@ -25,11 +32,15 @@ var (
 
 	optListFilterExpression string
 	optListShowBranch       bool
+	optListSortBy           string
+	optListShowQuota        bool
 )
 
 func init() {
-	listRepositoriesCmd.PersistentFlags().StringVarP(&optListFilterExpression, "filter", "f", "", "filter expression")
+	listRepositoriesCmd.PersistentFlags().StringVarP(&optListFilterExpression, "filter", "f", "", "filter expression (e.g. 'type=controller AND (name~=^s3 OR dirty=true)')")
+	listRepositoriesCmd.PersistentFlags().StringVar(&optListSortBy, "sort-by", "", "comma separated list of fields used to sort repositories, prefix a field with '-' for descending order (name|type|branch|state)")
 	listRepositoriesCmd.PersistentFlags().BoolVar(&optListShowBranch, "show-branch", true, "display project current branch or not")
+	listRepositoriesCmd.PersistentFlags().BoolVar(&optListShowQuota, "show-quota", false, "display the remaining Github API quota on the standard error")
 }
 
 var listRepositoriesCmd = &cobra.Command{
@@ -40,61 +51,112 @@ var listRepositoriesCmd = &cobra.Command{
 }
 
 func printRepositories(cmd *cobra.Command, args []string) error {
-	filters, err := repository.BuildFilters(optListFilterExpression)
+	expr, err := repository.ParseFilterExpression(optListFilterExpression)
 	if err != nil {
 		return err
 	}
 
-	repos, err := listRepositories(filters...)
+	var sortBy repository.By
+	if optListSortBy != "" {
+		sortBy, err = repository.ParseSortBy(optListSortBy)
+		if err != nil {
+			return err
+		}
+	}
+
+	p, err := newListPrinter(printer.FormatTable)
 	if err != nil {
 		return err
 	}
 
-	tablePrintRepositories(repos)
+	repos, err := listRepositories(expr)
+	if err != nil {
+		return err
+	}
+	if sortBy != nil {
+		sortBy.Sort(repos)
+	}
+
+	err = p.Print(os.Stdout, &repositoryList{Items: repos})
+	if err != nil {
+		return err
+	}
+	if optListShowQuota {
+		return printGithubQuota()
+	}
 	return nil
 }
 
-func listRepositories(filters ...repository.Filter) ([]*repository.Repository, error) {
+// printGithubQuota prints the remaining Github API quota on the standard error,
+// to not mix it with machine readable outputs.
+func printGithubQuota() error {
 	cfg, err := config.Load(ackConfigPath)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	repoManager, err := repository.NewManager(cfg)
+	ghc, err := newGithubClient(cfg)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	// Try to load all repositories
-	err = repoManager.LoadAll()
+	rate, err := ghc.RateLimit(context.Background())
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("cannot get Github API quota: %v", err)
+	}
+	reset := time.Until(rate.Reset.Time)
+	if reset < 0 {
+		reset = 0
 	}
+	fmt.Fprintf(
+		os.Stderr, "\nGithub API quota: %d/%d requests remaining, reset in %s\n",
+		rate.Remaining, rate.Limit, humanDuration(reset),
+	)
+	return nil
+}
 
-	// List repositories
-	//TODO(hilalymh) add sort-by flag/option
-	repos := repoManager.List(filters...)
+func listRepositories(expr *repository.FilterExpression) ([]*repository.Repository, error) {
+	repoManager, err := loadRepositoryManager()
 	if err != nil {
 		return nil, err
 	}
-	return repos, nil
-}
 
-func tablePrintRepositories(repos []*repository.Repository) {
-	tableHeaderColumns := listTableHeaderColumns
-	if optListShowBranch {
-		tableHeaderColumns = append(tableHeaderColumns, "Branch")
-	}
+	// List repositories
+	return filterRepositories(repoManager, repoManager.List(), expr)
+}
 
-	tw := newTable()
-	defer tw.Render()
+// repositoryList is the printable list of repositories
+type repositoryList struct {
+	Items []*repository.Repository `json:"items"`
+}
 
-	tw.SetHeader(tableHeaderColumns)
+// Table implements printer.Tabular
+func (l *repositoryList) Table(wide bool) ([]string, [][]string) {
+	header := append([]string{}, listTableHeaderColumns...)
+	if optListShowBranch || wide {
+		header = append(header, "Branch")
+	}
+	if wide {
+		header = append(header, "State", "Forked", "Path")
+	}
 
-	for _, repo := range repos {
-		rawArgs := []string{repo.Name, repo.Type.String()}
-		if optListShowBranch {
-			rawArgs = append(rawArgs, repo.GitHead)
+	rows := make([][]string, 0, len(l.Items))
+	for _, repo := range l.Items {
+		row := []string{repo.Name, repo.Type.String()}
+		if optListShowBranch || wide {
+			row = append(row, formatHead(repo))
 		}
-		tw.Append(rawArgs)
+		if wide {
+			row = append(row, repo.State.String(), strconv.FormatBool(repo.Forked), repo.FullPath)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// Names implements printer.Named
+func (l *repositoryList) Names() []string {
+	names := make([]string, 0, len(l.Items))
+	for _, repo := range l.Items {
+		names = append(names, repo.Name)
 	}
+	return names
 }
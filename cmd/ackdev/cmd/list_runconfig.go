@@ -0,0 +1,149 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/printer"
+)
+
+var (
+	listRunConfigTableHeaderColumns = []string{"Service", "Flags"}
+
+	optListRunConfigProfile string
+)
+
+func init() {
+	listRunConfigCmd.PersistentFlags().StringVarP(&optListRunConfigProfile, "profile", "p", "", "run profile applied on top of the run configuration")
+}
+
+var listRunConfigCmd = &cobra.Command{
+	Use:     "runconfig [service...]",
+	Aliases: []string{"runconfigs", "rc"},
+	Short:   "Display the effective run configuration of service controllers",
+	Long: `Display the flags, environment variables and working directory used by
+'ackdev run' for each service, once the service and profile overrides are applied.
+By default all the configured services are displayed.`,
+	RunE: printRunConfigs,
+}
+
+func printRunConfigs(cmd *cobra.Command, args []string) error {
+	p, err := newListPrinter(printer.FormatTable)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(ackConfigPath)
+	if err != nil {
+		return err
+	}
+
+	services := args
+	if len(services) == 0 {
+		services = cfg.Repositories.Services
+	}
+	// services are resolved like 'ackdev run' does, they can be referenced
+	// by their configured or repository names.
+	repoManager, err := newRepositoryManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	list := &runConfigList{Items: make([]*config.EffectiveRunConfig, 0, len(services))}
+	for _, service := range services {
+		repo, err := getServiceRepository(repoManager, service)
+		if err != nil {
+			return err
+		}
+		runConfig, err := cfg.RunConfig.Effective(serviceName(repo), optListRunConfigProfile)
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, runConfig)
+	}
+	return p.Print(os.Stdout, list)
+}
+
+// runConfigList is the printable list of effective run configurations
+type runConfigList struct {
+	Items []*config.EffectiveRunConfig `json:"items"`
+}
+
+// Table implements printer.Tabular
+func (l *runConfigList) Table(wide bool) ([]string, [][]string) {
+	showProfile := optListRunConfigProfile != ""
+	showEnv, showWorkDir := wide, wide
+	for _, item := range l.Items {
+		showEnv = showEnv || len(item.Env) > 0
+		showWorkDir = showWorkDir || item.WorkingDirectory != ""
+	}
+
+	header := append([]string{}, listRunConfigTableHeaderColumns...)
+	if showProfile {
+		header = append([]string{header[0], "Profile"}, header[1:]...)
+	}
+	if showEnv {
+		header = append(header, "Env")
+	}
+	if showWorkDir {
+		header = append(header, "Working Directory")
+	}
+
+	rows := make([][]string, 0, len(l.Items))
+	for _, item := range l.Items {
+		row := []string{item.Service}
+		if showProfile {
+			row = append(row, item.Profile)
+		}
+		row = append(row, strings.Join(flagsToArgs(item.Flags), " "))
+		if showEnv {
+			row = append(row, formatEnv(item.Env))
+		}
+		if showWorkDir {
+			workDir := item.WorkingDirectory
+			if workDir == "" {
+				workDir = "(repository)"
+			}
+			row = append(row, workDir)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// Names implements printer.Named
+func (l *runConfigList) Names() []string {
+	names := make([]string, 0, len(l.Items))
+	for _, item := range l.Items {
+		names = append(names, item.Service)
+	}
+	return names
+}
+
+// formatEnv returns the KEY=value representation of environment variables,
+// sorted by name.
+func formatEnv(env map[string]string) string {
+	vars := make([]string, 0, len(env))
+	for key, value := range env {
+		vars = append(vars, key+"="+value)
+	}
+	sort.Strings(vars)
+	return strings.Join(vars, " ")
+}
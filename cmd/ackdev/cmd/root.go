@@ -31,6 +31,12 @@ func init() {
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(setupCmd)
+	rootCmd.AddCommand(ensureCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(runCmd)
 }
 
 var rootCmd = &cobra.Command{
@@ -38,6 +44,9 @@ var rootCmd = &cobra.Command{
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	Short:         "A tool to manage ACK repositories, CRDs, development tools and testing",
+	PersistentPreRunE: func(*cobra.Command, []string) error {
+		return prependBinDirectoryToPath()
+	},
 }
 
 func Execute() {
@@ -0,0 +1,322 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/asyncexec"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/repository"
+)
+
+const (
+	// controllerMainPackage is the package of the service controllers main
+	// function, relative to the repository root.
+	controllerMainPackage = "./cmd/controller"
+	// runOutputBuffer is the number of controller log lines buffered
+	runOutputBuffer = 64
+)
+
+var (
+	optRunFlags     []string
+	optRunSkipBuild bool
+	optRunProfile   string
+)
+
+func init() {
+	runCmd.PersistentFlags().StringArrayVar(&optRunFlags, "flag", nil, "controller flag overriding the configured ones, in the key=value format. Can be repeated")
+	runCmd.PersistentFlags().BoolVar(&optRunSkipBuild, "skip-build", false, "run the previously built controller binary")
+	runCmd.PersistentFlags().StringVarP(&optRunProfile, "profile", "p", "", "run profile applied on top of the run configuration")
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <service> [-- args...]",
+	Short: "Build and run a service controller locally",
+	Long: `Build the controller of a service repository and run it locally, using the flags,
+environment variables and working directory of the run configuration, with the service
+and --profile overrides applied. Flags given with --flag override the configured ones
+and arguments after -- are passed as is to the controller. The controller logs are streamed
+to the terminal. Ctrl+C is forwarded to the controller for a clean shutdown, press it
+twice to force stop the controller.`,
+	Example: "ackdev run s3\nackdev run s3 --profile localstack\nackdev run s3 --flag aws-region=us-east-1\nackdev run s3 -- --kubeconfig ~/.kube/kind",
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runController,
+}
+
+func runController(cmd *cobra.Command, args []string) error {
+	if dash := cmd.ArgsLenAtDash(); dash == 0 || dash > 1 || (dash < 0 && len(args) > 1) {
+		return fmt.Errorf("expected exactly one service name")
+	}
+	service, extraArgs := args[0], args[1:]
+
+	overrides, err := parseFlagOverrides(optRunFlags)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(ackConfigPath)
+	if err != nil {
+		return err
+	}
+	repoManager, err := newRepositoryManager(cfg)
+	if err != nil {
+		return err
+	}
+	repo, err := getControllerRepository(repoManager, service)
+	if err != nil {
+		return err
+	}
+
+	runConfig, err := cfg.RunConfig.Effective(serviceName(repo), optRunProfile)
+	if err != nil {
+		return err
+	}
+	workDir, err := runWorkingDirectory(runConfig, repo)
+	if err != nil {
+		return err
+	}
+	flags := mergeFlags(runConfig.Flags, overrides)
+	controllerArgs := append(flagsToArgs(flags), extraArgs...)
+
+	// stop the build on SIGINT and SIGTERM, once the controller is started
+	// the signals are forwarded instead.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	binaryPath := filepath.Join(ackdevHomeDirectory, "run", repo.Name)
+	if !optRunSkipBuild {
+		err = buildController(sigCh, repo, binaryPath)
+		if err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(binaryPath); err != nil {
+		return fmt.Errorf("cannot skip the build: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "running %s %s\n", binaryPath, strings.Join(controllerArgs, " "))
+	return runControllerBinary(sigCh, repo, binaryPath, controllerArgs, runEnv(runConfig), workDir)
+}
+
+// serviceName returns the name of the service of a controller repository,
+// as configured in the repositories services.
+func serviceName(repo *repository.Repository) string {
+	if repo.ConfigName != "" {
+		return repo.ConfigName
+	}
+	return strings.TrimSuffix(repo.Name, "-controller")
+}
+
+// runWorkingDirectory returns the directory a controller is executed in.
+// Relative directories are relative to the repository path.
+func runWorkingDirectory(runConfig *config.EffectiveRunConfig, repo *repository.Repository) (string, error) {
+	if runConfig.WorkingDirectory == "" {
+		return repo.FullPath, nil
+	}
+	dir, err := homedir.Expand(os.ExpandEnv(runConfig.WorkingDirectory))
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo.FullPath, dir)
+	}
+	return dir, nil
+}
+
+// runEnv returns the environment of a controller: the ackdev environment
+// and the configured variables. Variables references in the configured values
+// are expanded.
+func runEnv(runConfig *config.EffectiveRunConfig) []string {
+	keys := make([]string, 0, len(runConfig.Env))
+	for key := range runConfig.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := os.Environ()
+	for _, key := range keys {
+		env = append(env, key+"="+os.ExpandEnv(runConfig.Env[key]))
+	}
+	return env
+}
+
+// getServiceRepository returns the repository of a service controller,
+// referenced by its configured name or its repository name.
+func getServiceRepository(repoManager *repository.Manager, service string) (*repository.Repository, error) {
+	repo, err := repoManager.GetRepository(service)
+	if err != nil {
+		return nil, fmt.Errorf("unknown service %s: %v", service, err)
+	}
+	if repo.Type != repository.RepositoryTypeController {
+		return nil, fmt.Errorf("repository %s is not a service controller", repo.Name)
+	}
+	return repo, nil
+}
+
+// getControllerRepository returns the cloned repository of a service
+// controller.
+func getControllerRepository(repoManager *repository.Manager, service string) (*repository.Repository, error) {
+	repo, err := getServiceRepository(repoManager, service)
+	if err != nil {
+		return nil, err
+	}
+	if repo.State == repository.RepositoryStateNotCloned {
+		return nil, fmt.Errorf("repository %s is not cloned, run 'ackdev ensure %s' first", repo.Name, service)
+	}
+	return repo, nil
+}
+
+// buildController builds the controller binary of a service repository. The
+// build is stopped if a signal is received.
+func buildController(sigCh <-chan os.Signal, repo *repository.Repository, binaryPath string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "building %s\n", repo.Name)
+	err := asyncexec.StreamCommandContext(
+		ctx, repo.FullPath,
+		"go", []string{"build", "-o", binaryPath, controllerMainPackage},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to build %s: %v", repo.Name, err)
+	}
+	return nil
+}
+
+// runControllerBinary runs a controller binary in the given directory and
+// streams its logs. The first received signal is forwarded to the
+// controller as an interrupt, the next ones stop it.
+func runControllerBinary(
+	sigCh <-chan os.Signal,
+	repo *repository.Repository,
+	binaryPath string,
+	args []string,
+	env []string,
+	workDir string,
+) error {
+	c := exec.Command(binaryPath, args...)
+	c.Dir = workDir
+	c.Env = env
+	acmd := asyncexec.New(c, runOutputBuffer)
+	if err := acmd.Run(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	stream := func(w *os.File, ch <-chan []byte) {
+		defer wg.Done()
+		for line := range ch {
+			_, _ = w.Write(append(line, '\n'))
+		}
+	}
+	go stream(os.Stdout, acmd.StdoutStream())
+	go stream(os.Stderr, acmd.StderrStream())
+
+	done := make(chan struct{})
+	forwarderDone := make(chan struct{})
+	interrupted := false
+	go func() {
+		defer close(forwarderDone)
+		for {
+			select {
+			case <-done:
+				return
+			case <-sigCh:
+			}
+			if !interrupted {
+				interrupted = true
+				fmt.Fprintf(os.Stderr, "stopping %s, press Ctrl+C again to force stop\n", repo.Name)
+				_ = acmd.Signal(os.Interrupt)
+				continue
+			}
+			acmd.Stop()
+		}
+	}()
+
+	wg.Wait()
+	err := acmd.Wait()
+	close(done)
+	<-forwarderDone
+	if err != nil && !(interrupted && acmd.ExitCode() <= 0) {
+		return fmt.Errorf("%s exited: %v", repo.Name, err)
+	}
+	return nil
+}
+
+// parseFlagOverrides parses key=value flag overrides.
+func parseFlagOverrides(overrides []string) (map[string]string, error) {
+	flags := make(map[string]string, len(overrides))
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		key := strings.TrimLeft(strings.TrimSpace(parts[0]), "-")
+		if len(parts) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid flag %q: expected key=value", override)
+		}
+		flags[key] = parts[1]
+	}
+	return flags, nil
+}
+
+// mergeFlags returns a new map containing the flags of all the given maps.
+// Later maps take precedence.
+func mergeFlags(flagMaps ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, flags := range flagMaps {
+		for key, value := range flags {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// flagsToArgs returns the command line arguments of a flags map, sorted by
+// flag name. Flags with an empty value are passed without value.
+func flagsToArgs(flags map[string]string) []string {
+	keys := make([]string, 0, len(flags))
+	for key := range flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if flags[key] == "" {
+			args = append(args, "--"+key)
+			continue
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", key, flags[key]))
+	}
+	return args
+}
@@ -0,0 +1,116 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/repository"
+)
+
+var (
+	statusTableHeaderColumns = []string{"Name", "Branch", "Worktree", "Untracked", "Upstream", "Origin", "Last Commit", "Age"}
+
+	optStatusFilterExpression string
+	optStatusWorkers          int
+)
+
+func init() {
+	statusCmd.PersistentFlags().StringVarP(&optStatusFilterExpression, "filter", "f", "", "filter expression")
+	statusCmd.PersistentFlags().IntVarP(&optStatusWorkers, "workers", "j", 4, "number of repositories inspected concurrently")
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status [repo...]",
+	Short: "Display the status of local repositories",
+	Long: `Display the worktree status of the local repositories, how many commits
+their current branch is ahead/behind upstream/main and origin, and their last
+commit. By default all the configured repositories are displayed.`,
+	Example: "ackdev status\nackdev status s3 runtime\nackdev status -f type=controller",
+	RunE:    printStatus,
+}
+
+func printStatus(cmd *cobra.Command, args []string) error {
+	repoManager, err := loadRepositoryManager()
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(repoManager, args, len(args) == 0, optStatusFilterExpression)
+	if err != nil {
+		return err
+	}
+
+	// filters on the repositories status already loaded it
+	unloaded := make([]*repository.Repository, 0, len(repos))
+	for _, repo := range repos {
+		if repo.Status == nil {
+			unloaded = append(unloaded, repo)
+		}
+	}
+	err = repoManager.LoadStatus(optStatusWorkers, unloaded...)
+	if err != nil {
+		return err
+	}
+
+	tablePrintStatus(repos)
+	return nil
+}
+
+func tablePrintStatus(repos []*repository.Repository) {
+	tw := newTable()
+	defer tw.Render()
+
+	tw.SetHeader(statusTableHeaderColumns)
+
+	for _, repo := range repos {
+		status := repo.Status
+		if status == nil {
+			tw.Append([]string{repo.Name, formatHead(repo), "-", "-", "-", "-", "-", "-"})
+			continue
+		}
+
+		worktree := "clean"
+		if status.Dirty {
+			worktree = "dirty"
+		}
+		lastCommitAge := "-"
+		if !status.LastCommitDate.IsZero() {
+			lastCommitAge = humanDuration(time.Since(status.LastCommitDate))
+		}
+		tw.Append([]string{
+			repo.Name,
+			formatHead(repo),
+			worktree,
+			strconv.Itoa(status.UntrackedFiles),
+			formatDivergence(status.Upstream),
+			formatDivergence(status.Origin),
+			status.LastCommitSubject,
+			lastCommitAge,
+		})
+	}
+}
+
+// formatDivergence returns a short representation of a Divergence such as
+// "+1/-3". It returns "-" if the divergence is unknown.
+func formatDivergence(d *repository.Divergence) string {
+	if d == nil {
+		return "-"
+	}
+	return fmt.Sprintf("+%d/-%d", d.Ahead, d.Behind)
+}
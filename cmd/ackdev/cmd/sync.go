@@ -0,0 +1,96 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/repository"
+)
+
+var (
+	syncTableHeaderColumns = []string{"Name", "Ahead", "Behind", "Result", "Reason"}
+
+	optSyncAll              bool
+	optSyncFilterExpression string
+	optSyncWorkers          int
+)
+
+func init() {
+	syncCmd.PersistentFlags().BoolVar(&optSyncAll, "all", false, "synchronise all the configured repositories")
+	syncCmd.PersistentFlags().StringVarP(&optSyncFilterExpression, "filter", "f", "", "filter expression")
+	syncCmd.PersistentFlags().IntVarP(&optSyncWorkers, "workers", "j", 4, "number of repositories synchronised concurrently")
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [repo...]",
+	Short: "Synchronise local repositories and forks with upstream",
+	Long: `Fetch the upstream remote of the selected repositories, fast-forward their
+local main branch and push it to origin. Repositories with uncommitted changes,
+checked out on a different branch or with local commits that are not present
+upstream are skipped.`,
+	Example: "ackdev sync s3 runtime\nackdev sync --all\nackdev sync -f type=controller",
+	RunE:    syncRepositories,
+}
+
+func syncRepositories(cmd *cobra.Command, args []string) error {
+	repoManager, err := loadRepositoryManager()
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(repoManager, args, optSyncAll, optSyncFilterExpression)
+	if err != nil {
+		return err
+	}
+
+	results := repoManager.Sync(context.Background(), optSyncWorkers, repos...)
+	tablePrintSyncResults(results)
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to synchronise %d/%d repositories", failed, len(results))
+	}
+	return nil
+}
+
+func tablePrintSyncResults(results []*repository.SyncResult) {
+	tw := newTable()
+	defer tw.Render()
+
+	tw.SetHeader(syncTableHeaderColumns)
+
+	for _, res := range results {
+		reason := res.Reason
+		if res.Err != nil {
+			reason = res.Err.Error()
+		}
+		tw.Append([]string{
+			res.Repository.Name,
+			strconv.Itoa(res.Ahead),
+			strconv.Itoa(res.Behind),
+			string(res.Status),
+			reason,
+		})
+	}
+}
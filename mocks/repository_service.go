@@ -15,18 +15,27 @@ type RepositoryService struct {
 	mock.Mock
 }
 
-// ForkRepository provides a mock function with given fields: ctx, repoName
-func (_m *RepositoryService) ForkRepository(ctx context.Context, repoName string) error {
-	ret := _m.Called(ctx, repoName)
+// ForkRepository provides a mock function with given fields: ctx, owner, repoName
+func (_m *RepositoryService) ForkRepository(ctx context.Context, owner string, repoName string) (*v35github.Repository, error) {
+	ret := _m.Called(ctx, owner, repoName)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, repoName)
+	var r0 *v35github.Repository
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v35github.Repository); ok {
+		r0 = rf(ctx, owner, repoName)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v35github.Repository)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, owner, repoName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // GetRepository provides a mock function with given fields: ctx, owner, repoName
@@ -52,13 +61,13 @@ func (_m *RepositoryService) GetRepository(ctx context.Context, owner string, re
 	return r0, r1
 }
 
-// GetUserRepositoryFork provides a mock function with given fields: ctx, owner, repoName
-func (_m *RepositoryService) GetUserRepositoryFork(ctx context.Context, owner string, repoName string) (*v35github.Repository, error) {
-	ret := _m.Called(ctx, owner, repoName)
+// GetUserRepositoryFork provides a mock function with given fields: ctx, owner, upstreamOwner, repoName, expectedForkName
+func (_m *RepositoryService) GetUserRepositoryFork(ctx context.Context, owner string, upstreamOwner string, repoName string, expectedForkName string) (*v35github.Repository, error) {
+	ret := _m.Called(ctx, owner, upstreamOwner, repoName, expectedForkName)
 
 	var r0 *v35github.Repository
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v35github.Repository); ok {
-		r0 = rf(ctx, owner, repoName)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) *v35github.Repository); ok {
+		r0 = rf(ctx, owner, upstreamOwner, repoName, expectedForkName)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*v35github.Repository)
@@ -66,8 +75,8 @@ func (_m *RepositoryService) GetUserRepositoryFork(ctx context.Context, owner st
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, owner, repoName)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, owner, upstreamOwner, repoName, expectedForkName)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -75,13 +84,13 @@ func (_m *RepositoryService) GetUserRepositoryFork(ctx context.Context, owner st
 	return r0, r1
 }
 
-// ListRepositoryForks provides a mock function with given fields: ctx, repoName
-func (_m *RepositoryService) ListRepositoryForks(ctx context.Context, repoName string) ([]*v35github.Repository, error) {
-	ret := _m.Called(ctx, repoName)
+// ListRepositoryForks provides a mock function with given fields: ctx, owner, repoName
+func (_m *RepositoryService) ListRepositoryForks(ctx context.Context, owner string, repoName string) ([]*v35github.Repository, error) {
+	ret := _m.Called(ctx, owner, repoName)
 
 	var r0 []*v35github.Repository
-	if rf, ok := ret.Get(0).(func(context.Context, string) []*v35github.Repository); ok {
-		r0 = rf(ctx, repoName)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*v35github.Repository); ok {
+		r0 = rf(ctx, owner, repoName)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*v35github.Repository)
@@ -89,8 +98,8 @@ func (_m *RepositoryService) ListRepositoryForks(ctx context.Context, repoName s
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, repoName)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, owner, repoName)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -111,3 +120,26 @@ func (_m *RepositoryService) RenameRepository(ctx context.Context, owner string,
 
 	return r0
 }
+
+// WaitForFork provides a mock function with given fields: ctx, owner, upstreamOwner, repoName, forkName
+func (_m *RepositoryService) WaitForFork(ctx context.Context, owner string, upstreamOwner string, repoName string, forkName string) (*v35github.Repository, error) {
+	ret := _m.Called(ctx, owner, upstreamOwner, repoName, forkName)
+
+	var r0 *v35github.Repository
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) *v35github.Repository); ok {
+		r0 = rf(ctx, owner, upstreamOwner, repoName, forkName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v35github.Repository)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, owner, upstreamOwner, repoName, forkName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -2,84 +2,320 @@ package asyncexec
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"os"
 	"os/exec"
+	"sync"
+	"time"
 )
 
+const (
+	// DefaultGracePeriod is the default duration between the SIGTERM and
+	// SIGKILL signals sent to a stopped command.
+	DefaultGracePeriod = 5 * time.Second
+	// outputCloseDelay is how long the outputs of an exited command are read
+	// before their pipes are closed. Processes that outlive the command, and
+	// inherited its outputs, would otherwise keep Wait blocked.
+	outputCloseDelay = time.Second
+)
+
+var (
+	// ErrNotStarted is returned when waiting for a command that wasn't
+	// started.
+	ErrNotStarted = errors.New("command not started")
+	// ErrExited is returned when signaling a command that already exited.
+	ErrExited = errors.New("command already exited")
+)
+
+// Option is a function that configures a Cmd.
+type Option func(*Cmd)
+
+// WithTimeout bounds the command execution time. When the timeout expires
+// the command is stopped.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Cmd) {
+		c.timeout = timeout
+	}
+}
+
+// WithGracePeriod sets the duration between the SIGTERM and SIGKILL signals
+// sent to a stopped command.
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(c *Cmd) {
+		c.gracePeriod = gracePeriod
+	}
+}
+
 // New instantiate a new Cmd object.
 func New(cmd *exec.Cmd, buff int) *Cmd {
-	return &Cmd{
-		cmd:      cmd,
-		stopCh:   make(chan struct{}),
-		stdoutCh: make(chan []byte, buff),
-		stderrCh: make(chan []byte, buff),
+	return NewWithContext(context.Background(), cmd, buff)
+}
+
+// NewWithContext instantiate a new Cmd object. The command is stopped
+// when ctx is done.
+func NewWithContext(ctx context.Context, cmd *exec.Cmd, buff int, opts ...Option) *Cmd {
+	c := &Cmd{
+		cmd:         cmd,
+		ctx:         ctx,
+		gracePeriod: DefaultGracePeriod,
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+		stdoutCh:    make(chan []byte, buff),
+		stderrCh:    make(chan []byte, buff),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Cmd is a wrapper arround exec.Cmd. Mainly used to execute
 // command asynchronously with/or without output stream.
+//
+// Commands run in their own process group. When a command is stopped,
+// because Stop was called, its context is done or its timeout expired, the
+// whole process group receives SIGTERM and, if it is still running after
+// the grace period, SIGKILL. This ensures that grandchildren processes
+// (e.g spawned by 'go run' or 'make') are cleaned up too.
 type Cmd struct {
 	cmd *exec.Cmd
 
+	ctx         context.Context
+	timeout     time.Duration
+	gracePeriod time.Duration
+
+	stopOnce sync.Once
 	stopCh   chan struct{}
 	stdoutCh chan []byte
 	stderrCh chan []byte
+	// readers is used to wait for the output readers to reach EOF once the
+	// command exited.
+	readers sync.WaitGroup
+
+	mu      sync.Mutex
+	started bool
+	// exited is set once the command process exited
+	exited bool
+	// terminated is set when the command is stopped before it exited
+	terminated bool
+	// doneCh is closed once the command exited and err is set
+	doneCh chan struct{}
+	err    error
 }
 
 // Run runs the command. if streamOutput is true, it will spin
 // two goroutine responsible of streaming the stdout and stderr
 func (c *Cmd) Run() error {
-	cmdStdoutReader, err := c.cmd.StdoutPipe()
+	if c.cmd.Stdout != nil {
+		return errors.New("exec: Stdout already set")
+	}
+	if c.cmd.Stderr != nil {
+		return errors.New("exec: Stderr already set")
+	}
+
+	ctx, cancel := c.ctx, context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	// fail early if the context is already done
+	if err := ctx.Err(); err != nil {
+		cancel()
+		c.closeStreams()
+		return err
+	}
+
+	// The pipes are created here rather than using StdoutPipe/StderrPipe,
+	// so that waiting for the command doesn't close them before the outputs
+	// are fully read.
+	pipes, err := newOutputPipes()
 	if err != nil {
+		cancel()
+		c.closeStreams()
 		return err
 	}
-	stdoutScanner := bufio.NewScanner(cmdStdoutReader)
+	c.cmd.Stdout = pipes.stdoutWriter
+	c.cmd.Stderr = pipes.stderrWriter
+	stdoutScanner := bufio.NewScanner(pipes.stdoutReader)
+	stderrScanner := bufio.NewScanner(pipes.stderrReader)
 
-	cmdStderrReader, err := c.cmd.StderrPipe()
+	setProcessGroup(c.cmd)
+	err = c.cmd.Start()
+	// the command holds its own copies of the write ends
+	pipes.closeWriters()
 	if err != nil {
+		pipes.closeReaders()
+		cancel()
+		c.closeStreams()
 		return err
 	}
-	stderrScanner := bufio.NewScanner(cmdStderrReader)
+	c.mu.Lock()
+	c.started = true
+	c.mu.Unlock()
 
+	c.readers.Add(2)
 	// Goroutine for stdout
 	go func() {
+		defer c.readers.Done()
 		defer close(c.stdoutCh)
 		for stdoutScanner.Scan() {
-			bytes := stdoutScanner.Bytes()
+			// the scanner reuses its buffer, copy the line before sending it
+			bytes := append([]byte(nil), stdoutScanner.Bytes()...)
 			c.stdoutCh <- bytes
 		}
 	}()
 
 	// Goroutine for stderr
 	go func() {
+		defer c.readers.Done()
 		defer close(c.stderrCh)
 		for stderrScanner.Scan() {
-			bytes := stderrScanner.Bytes()
+			bytes := append([]byte(nil), stderrScanner.Bytes()...)
 			c.stderrCh <- bytes
 		}
 	}()
 
-	err = c.cmd.Start()
-	if err != nil {
-		return err
-	}
+	// Goroutine waiting for the command to exit
+	go func() {
+		defer cancel()
+		err := c.cmd.Wait()
+		c.mu.Lock()
+		c.exited = true
+		terminated := c.terminated
+		c.mu.Unlock()
+		// report the context error if the command was stopped because
+		// of the context or the timeout, not if it failed on its own
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil && terminated {
+			err = ctxErr
+		}
+
+		// read the remaining outputs, unless other processes keep the pipes
+		// open
+		readersDone := make(chan struct{})
+		go func() {
+			c.readers.Wait()
+			close(readersDone)
+		}()
+		timer := time.NewTimer(outputCloseDelay)
+		select {
+		case <-readersDone:
+		case <-timer.C:
+		}
+		timer.Stop()
+		pipes.closeReaders()
+		<-readersDone
 
-	// listening for stop signal
+		c.err = err
+		close(c.doneCh)
+	}()
+
+	// listening for stop signals
 	go func() {
-		<-c.stopCh
-		c.cmd.Process.Kill()
+		select {
+		case <-c.doneCh:
+			return
+		case <-ctx.Done():
+		case <-c.stopCh:
+		}
+		c.terminate()
 	}()
 
 	return nil
 }
 
-// Exited returns true if the command exited, false otherwise.
+// terminate sends SIGTERM to the command process group, then SIGKILL if
+// the command, or any process of its group, is still running after the
+// grace period. The command can exit while its children ignore SIGTERM.
+func (c *Cmd) terminate() {
+	c.mu.Lock()
+	if !c.exited {
+		c.terminated = true
+	}
+	c.mu.Unlock()
+	_ = terminateProcessGroup(c.cmd.Process)
+
+	timer := time.NewTimer(c.gracePeriod)
+	defer timer.Stop()
+	select {
+	case <-c.doneCh:
+		if !processGroupAlive(c.cmd.Process) {
+			return
+		}
+		<-timer.C
+	case <-timer.C:
+	}
+	// the process group may be empty already, the error is ignored
+	_ = killProcessGroup(c.cmd.Process)
+}
+
+// outputPipes are the pipes connected to the outputs of a command.
+type outputPipes struct {
+	stdoutReader, stdoutWriter *os.File
+	stderrReader, stderrWriter *os.File
+}
+
+// newOutputPipes creates the stdout and stderr pipes of a command.
+func newOutputPipes() (*outputPipes, error) {
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		stdoutReader.Close()
+		stdoutWriter.Close()
+		return nil, err
+	}
+	return &outputPipes{
+		stdoutReader: stdoutReader,
+		stdoutWriter: stdoutWriter,
+		stderrReader: stderrReader,
+		stderrWriter: stderrWriter,
+	}, nil
+}
+
+// closeWriters closes the write ends of the pipes.
+func (p *outputPipes) closeWriters() {
+	p.stdoutWriter.Close()
+	p.stderrWriter.Close()
+}
+
+// closeReaders closes the read ends of the pipes, which stops the pending
+// reads.
+func (p *outputPipes) closeReaders() {
+	p.stdoutReader.Close()
+	p.stderrReader.Close()
+}
+
+// closeStreams closes the output streams of a command that couldn't start
+func (c *Cmd) closeStreams() {
+	close(c.stdoutCh)
+	close(c.stderrCh)
+}
+
+// Exited returns true if the command exited, false otherwise. It returns
+// false if the command is still running or was killed by a signal.
 func (c *Cmd) Exited() bool {
-	return c.cmd.ProcessState.Exited()
+	select {
+	case <-c.doneCh:
+		return c.cmd.ProcessState != nil && c.cmd.ProcessState.Exited()
+	default:
+		return false
+	}
 }
 
-// ExitCode returns the command process exit code.
+// ExitCode returns the command process exit code. It returns -1 if the
+// command is still running or was killed by a signal.
 func (c *Cmd) ExitCode() int {
-	return c.cmd.ProcessState.ExitCode()
+	select {
+	case <-c.doneCh:
+		if c.cmd.ProcessState == nil {
+			return -1
+		}
+		return c.cmd.ProcessState.ExitCode()
+	default:
+		return -1
+	}
 }
 
 // StdoutStream returns a channel streaming the command Stdout.
@@ -92,12 +328,49 @@ func (c *Cmd) StderrStream() <-chan []byte {
 	return c.stderrCh
 }
 
-// Wait blocks until the command exits
+// Wait blocks until the command exits and its outputs are fully read. Outputs
+// still held open by other processes, for example a daemon started by the
+// command, are read for one more second at most. The
+// output streams must be consumed, or buffered enough, for Wait to return.
+// If the command was stopped because its context is done or its timeout
+// expired, the context error is returned. A command that exited on its own
+// before being stopped returns its own error.
 func (c *Cmd) Wait() error {
-	return c.cmd.Wait()
+	c.mu.Lock()
+	started := c.started
+	c.mu.Unlock()
+	if !started {
+		return ErrNotStarted
+	}
+
+	<-c.doneCh
+	return c.err
+}
+
+// Signal sends a signal to the process group of a running command, for
+// example to forward an interrupt received by the parent process.
+func (c *Cmd) Signal(sig os.Signal) error {
+	c.mu.Lock()
+	started := c.started
+	c.mu.Unlock()
+	if !started {
+		return ErrNotStarted
+	}
+
+	select {
+	case <-c.doneCh:
+		return ErrExited
+	default:
+		return signalProcessGroup(c.cmd.Process, sig)
+	}
 }
 
-// Stop signals the Wrapper to kill the process running the command.
+// Stop signals the Wrapper to stop the process running the command. The
+// command process group receives SIGTERM, then SIGKILL if it is still
+// running after the grace period. Stop doesn't block and can be called
+// multiple times.
 func (c *Cmd) Stop() {
-	c.stopCh <- struct{}{}
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
 }
@@ -3,8 +3,20 @@
 package asyncexec_test
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/aws-controllers-k8s/dev-tools/pkg/asyncexec"
 )
@@ -42,3 +54,257 @@ func ExampleCmd_Run_withStream() {
 	cmd.Wait()
 	// Output: Hello ACK
 }
+
+// drain consumes the command output streams and returns the stdout lines
+// once both streams are closed.
+func drain(cmd *asyncexec.Cmd) <-chan []string {
+	linesCh := make(chan []string, 1)
+	go func() {
+		var lines []string
+		done := make(chan struct{})
+		go func() {
+			for range cmd.StderrStream() {
+			}
+			close(done)
+		}()
+		for b := range cmd.StdoutStream() {
+			lines = append(lines, string(b))
+		}
+		<-done
+		linesCh <- lines
+	}()
+	return linesCh
+}
+
+// processAlive returns true if a process with the given pid is running.
+// Zombies, which are dead but not reaped yet, are not running.
+func processAlive(pid int) bool {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if os.IsNotExist(err) {
+		if _, err := os.Stat("/proc/self/stat"); err == nil {
+			return false
+		}
+		// no procfs, zombies can't be told apart
+		return syscall.Kill(pid, 0) == nil
+	}
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil
+	}
+	// the state follows the command name, which is in parentheses
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 || i+2 >= len(stat) {
+		return true
+	}
+	state := stat[i+2]
+	return state != 'Z' && state != 'X'
+}
+
+func TestCmd_ExitedBeforeWait(t *testing.T) {
+	cmd := asyncexec.New(exec.Command("sleep", "10"), 1)
+	assert.False(t, cmd.Exited())
+	assert.Equal(t, -1, cmd.ExitCode())
+	assert.Equal(t, asyncexec.ErrNotStarted, cmd.Wait())
+
+	require.NoError(t, cmd.Run())
+	assert.False(t, cmd.Exited())
+	assert.Equal(t, -1, cmd.ExitCode())
+
+	cmd.Stop()
+	// Stop must not block nor panic when called multiple times
+	cmd.Stop()
+	<-drain(cmd)
+	assert.Error(t, cmd.Wait())
+	assert.False(t, cmd.Exited())
+	assert.Equal(t, -1, cmd.ExitCode())
+}
+
+func TestCmd_ExitCode(t *testing.T) {
+	cmd := asyncexec.New(exec.Command("sh", "-c", "echo out; exit 3"), 1)
+	require.NoError(t, cmd.Run())
+	lines := drain(cmd)
+	assert.Error(t, cmd.Wait())
+	assert.Equal(t, []string{"out"}, <-lines)
+	assert.True(t, cmd.Exited())
+	assert.Equal(t, 3, cmd.ExitCode())
+	// Stop after exit is a no-op
+	cmd.Stop()
+}
+
+func TestCmd_RunError(t *testing.T) {
+	cmd := asyncexec.New(exec.Command("/nonexistent/binary"), 1)
+	require.Error(t, cmd.Run())
+	// streams are closed
+	assert.Empty(t, <-drain(cmd))
+	assert.Equal(t, asyncexec.ErrNotStarted, cmd.Wait())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cmd = asyncexec.NewWithContext(ctx, exec.Command("true"), 1)
+	assert.Equal(t, context.Canceled, cmd.Run())
+}
+
+func TestCmd_Timeout(t *testing.T) {
+	cmd := asyncexec.NewWithContext(
+		context.Background(),
+		exec.Command("sleep", "10"), 1,
+		asyncexec.WithTimeout(100*time.Millisecond),
+	)
+	start := time.Now()
+	require.NoError(t, cmd.Run())
+	<-drain(cmd)
+	assert.Equal(t, context.DeadlineExceeded, cmd.Wait())
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, -1, cmd.ExitCode())
+}
+
+func TestCmd_ContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := asyncexec.NewWithContext(ctx, exec.Command("sleep", "10"), 1)
+	require.NoError(t, cmd.Run())
+	lines := drain(cmd)
+	cancel()
+	<-lines
+	assert.Equal(t, context.Canceled, cmd.Wait())
+}
+
+func TestCmd_ContextCancelAfterExit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// the background process keeps the outputs open after the shell failed
+	c := exec.Command("sh", "-c", "sleep 10 & exit 3")
+	cmd := asyncexec.NewWithContext(ctx, c, 1, asyncexec.WithGracePeriod(100*time.Millisecond))
+	require.NoError(t, cmd.Run())
+	defer syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	lines := drain(cmd)
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-lines
+
+	// the command failed before being cancelled
+	err := cmd.Wait()
+	_, ok := err.(*exec.ExitError)
+	assert.True(t, ok, "unexpected error %v", err)
+	assert.Equal(t, 3, cmd.ExitCode())
+}
+
+func TestCmd_KillsProcessGroup(t *testing.T) {
+	// the shell spawns a grandchild and reports its pid
+	c := exec.Command("sh", "-c", "sleep 10 & echo $!; wait")
+	cmd := asyncexec.NewWithContext(
+		context.Background(), c, 1,
+		asyncexec.WithTimeout(200*time.Millisecond),
+	)
+	require.NoError(t, cmd.Run())
+	lines := drain(cmd)
+	assert.Equal(t, context.DeadlineExceeded, cmd.Wait())
+
+	out := <-lines
+	require.Len(t, out, 1)
+	pid, err := strconv.Atoi(out[0])
+	require.NoError(t, err)
+	// the grandchild is killed, but might not be reaped by init yet
+	assert.Eventually(t, func() bool { return !processAlive(pid) }, 2*time.Second, 10*time.Millisecond)
+	assert.False(t, processAlive(c.Process.Pid))
+}
+
+func TestCmd_KillsProcessGroupAfterExit(t *testing.T) {
+	// the shell exits on SIGTERM but its grandchild ignores it. The
+	// grandchild releases the outputs so that the command is done as soon
+	// as the shell exits.
+	c := exec.Command("sh", "-c", "sh -c \"trap '' TERM; echo \\$\\$; exec >/dev/null 2>&1; while true; do sleep 0.05; done\" & wait")
+	cmd := asyncexec.NewWithContext(
+		context.Background(), c, 1,
+		asyncexec.WithGracePeriod(500*time.Millisecond),
+	)
+	require.NoError(t, cmd.Run())
+	b := <-cmd.StdoutStream()
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	require.NoError(t, err)
+	defer syscall.Kill(pid, syscall.SIGKILL)
+	lines := drain(cmd)
+
+	cmd.Stop()
+	<-lines
+	cmd.Wait()
+	assert.False(t, processAlive(c.Process.Pid))
+	assert.True(t, processAlive(pid), "the grandchild should only be killed after the grace period")
+	assert.Eventually(t, func() bool { return !processAlive(pid) }, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestCmd_OutputsHeldByOtherProcesses(t *testing.T) {
+	// the background process inherits the outputs and outlives the shell
+	c := exec.Command("sh", "-c", "sleep 10 & echo done")
+	cmd := asyncexec.New(c, 1)
+	start := time.Now()
+	require.NoError(t, cmd.Run())
+	defer syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+
+	lines := drain(cmd)
+	assert.NoError(t, cmd.Wait())
+	assert.Equal(t, []string{"done"}, <-lines)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, 0, cmd.ExitCode())
+}
+
+func TestCmd_Signal(t *testing.T) {
+	cmd := asyncexec.New(exec.Command("sh", "-c", "trap 'echo interrupted; exit 0' INT; echo ready; while true; do sleep 0.05; done"), 1)
+	assert.Equal(t, asyncexec.ErrNotStarted, cmd.Signal(os.Interrupt))
+	require.NoError(t, cmd.Run())
+	b := <-cmd.StdoutStream()
+	require.Equal(t, "ready", string(b))
+
+	lines := drain(cmd)
+	require.NoError(t, cmd.Signal(os.Interrupt))
+	assert.Equal(t, []string{"interrupted"}, <-lines)
+	assert.NoError(t, cmd.Wait())
+	assert.Equal(t, asyncexec.ErrExited, cmd.Signal(os.Interrupt))
+}
+
+func TestCmd_GracePeriod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asyncexec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "terminated")
+
+	tests := []struct {
+		name        string
+		script      string
+		gracePeriod time.Duration
+		wantMarker  bool
+	}{
+		{
+			name:        "graceful shutdown on SIGTERM",
+			script:      "trap 'echo bye > " + marker + "; exit 0' TERM; echo ready; while true; do sleep 0.05; done",
+			gracePeriod: 5 * time.Second,
+			wantMarker:  true,
+		},
+		{
+			name:        "SIGKILL after grace period",
+			script:      "trap '' TERM; echo ready; while true; do sleep 0.05; done",
+			gracePeriod: 200 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(marker)
+			cmd := asyncexec.NewWithContext(
+				context.Background(),
+				exec.Command("sh", "-c", tt.script), 1,
+				asyncexec.WithGracePeriod(tt.gracePeriod),
+			)
+			require.NoError(t, cmd.Run())
+			// wait for the trap to be installed before stopping
+			b := <-cmd.StdoutStream()
+			require.Equal(t, "ready", strings.TrimSpace(string(b)))
+			lines := drain(cmd)
+
+			start := time.Now()
+			cmd.Stop()
+			<-lines
+			cmd.Wait()
+			assert.True(t, time.Since(start) < 5*time.Second)
+
+			_, err := os.Stat(marker)
+			assert.Equal(t, tt.wantMarker, err == nil)
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package asyncexec
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	colorReset = "\x1b[0m"
+)
+
+var (
+	// jobColors are the ANSI colors used to prefix the jobs output, in
+	// order of job creation.
+	jobColors = []string{
+		"\x1b[36m", // cyan
+		"\x1b[33m", // yellow
+		"\x1b[32m", // green
+		"\x1b[35m", // magenta
+		"\x1b[34m", // blue
+		"\x1b[96m", // bright cyan
+		"\x1b[93m", // bright yellow
+		"\x1b[92m", // bright green
+		"\x1b[95m", // bright magenta
+		"\x1b[94m", // bright blue
+	}
+
+	// logFileNameRegex matches the characters replaced in log file names
+	logFileNameRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// MultiplexerOption is a function that configures a Multiplexer.
+type MultiplexerOption func(*Multiplexer)
+
+// WithColor enables or disables the coloured job prefixes.
+func WithColor(color bool) MultiplexerOption {
+	return func(m *Multiplexer) {
+		m.color = color
+	}
+}
+
+// WithLogDirectory tees the output of each job to a log file in dir, named
+// after the job, see LogFileName.
+func WithLogDirectory(dir string) MultiplexerOption {
+	return func(m *Multiplexer) {
+		m.logDir = dir
+	}
+}
+
+// NewMultiplexer instantiate a new Multiplexer writing to out.
+func NewMultiplexer(out io.Writer, opts ...MultiplexerOption) *Multiplexer {
+	m := &Multiplexer{out: out}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Multiplexer writes the output of concurrent jobs to a single writer,
+// prefixing each line with the job name, similar to what docker-compose
+// does. Lines are written atomically, so lines of different jobs never
+// interleave.
+//
+// Prefixes are padded to the longest job name, creating all the jobs
+// before writing their output keeps the lines aligned.
+type Multiplexer struct {
+	out    io.Writer
+	color  bool
+	logDir string
+
+	mu    sync.Mutex
+	jobs  int
+	width int
+	// logFiles are the names of the log files created by the jobs
+	logFiles map[string]bool
+}
+
+// Job returns a new job writing its output through the multiplexer. If a
+// log directory is configured, the job log file is created, or truncated.
+// An error is returned if another job of the multiplexer uses the same log
+// file. Callers must Close the job once its output is fully written.
+func (m *Multiplexer) Job(name string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var logFile *os.File
+	if m.logDir != "" {
+		fileName := LogFileName(name)
+		if m.logFiles[fileName] {
+			return nil, fmt.Errorf("job %s: log file %s is already used by another job", name, fileName)
+		}
+		if err := os.MkdirAll(m.logDir, 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.Create(filepath.Join(m.logDir, fileName))
+		if err != nil {
+			return nil, err
+		}
+		if m.logFiles == nil {
+			m.logFiles = map[string]bool{}
+		}
+		m.logFiles[fileName] = true
+		logFile = f
+	}
+
+	color := ""
+	if m.color {
+		color = jobColors[m.jobs%len(jobColors)]
+	}
+	m.jobs++
+	if len(name) > m.width {
+		m.width = len(name)
+	}
+
+	j := &Job{
+		mux:     m,
+		name:    name,
+		color:   color,
+		logFile: logFile,
+	}
+	j.Stdout = &lineWriter{job: j}
+	j.Stderr = &lineWriter{job: j}
+	return j, nil
+}
+
+// writeLine writes a prefixed line to the multiplexer output. line must
+// not contain the trailing new line.
+func (m *Multiplexer) writeLine(j *Job, line []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var buf bytes.Buffer
+	prefix := fmt.Sprintf("%-*s |", m.width, j.name)
+	if j.color != "" {
+		buf.WriteString(j.color + prefix + colorReset)
+	} else {
+		buf.WriteString(prefix)
+	}
+	if len(line) > 0 {
+		buf.WriteByte(' ')
+		buf.Write(line)
+	}
+	buf.WriteByte('\n')
+	// a single write per line keeps it atomic
+	_, err := m.out.Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if j.logFile != nil {
+		// log files don't need the prefix
+		_, err = j.logFile.Write(buf.Bytes()[buf.Len()-len(line)-1:])
+	}
+	return err
+}
+
+// LogFileName returns the name of the log file of a job. Characters that
+// aren't safe in file names are replaced, in which case a short hash of the
+// job name is appended so that names differing only by these characters
+// don't share a log file.
+func LogFileName(name string) string {
+	sanitized := strings.Trim(logFileNameRegex.ReplaceAllString(name, "_"), "_")
+	if sanitized == name {
+		return name + ".log"
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return fmt.Sprintf("%s-%08x.log", sanitized, h.Sum32())
+}
+
+// Job is a named producer of output lines, writing through a Multiplexer.
+type Job struct {
+	// Stdout and Stderr are line buffered writers. Incomplete lines are
+	// flushed when the job is closed.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	mux     *Multiplexer
+	name    string
+	color   string
+	logFile *os.File
+}
+
+// Name returns the job name.
+func (j *Job) Name() string {
+	return j.name
+}
+
+// Stream writes the output streams of a command until they are closed. It
+// is meant to be called after Cmd.Run and before Cmd.Wait.
+func (j *Job) Stream(cmd *Cmd) error {
+	errCh := make(chan error, 2)
+	stream := func(ch <-chan []byte) {
+		var err error
+		for line := range ch {
+			// keep draining the stream even if writing failed, to not block
+			// the command
+			if err == nil {
+				err = j.mux.writeLine(j, line)
+			}
+		}
+		errCh <- err
+	}
+	go stream(cmd.StdoutStream())
+	go stream(cmd.StderrStream())
+
+	err1, err2 := <-errCh, <-errCh
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// Close flushes the incomplete lines and closes the job log file.
+func (j *Job) Close() error {
+	var err error
+	for _, w := range []io.Writer{j.Stdout, j.Stderr} {
+		if flushErr := w.(*lineWriter).flush(); flushErr != nil && err == nil {
+			err = flushErr
+		}
+	}
+	if j.logFile != nil {
+		if closeErr := j.logFile.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// lineWriter is an io.Writer buffering the written bytes until a full line
+// is available.
+type lineWriter struct {
+	job *Job
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write implements io.Writer.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(w.buf[:i], []byte{'\r'})
+		if err := w.job.mux.writeLine(w.job, line); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush writes the buffered incomplete line, if any.
+func (w *lineWriter) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.job.mux.writeLine(w.job, line)
+}
@@ -0,0 +1,153 @@
+// +build !windows
+
+package asyncexec_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/asyncexec"
+)
+
+func TestMultiplexer(t *testing.T) {
+	tests := []struct {
+		name   string
+		color  bool
+		writes func(t *testing.T, s3, ec2 *asyncexec.Job)
+		want   string
+	}{
+		{
+			name: "prefixes are padded to the longest job name",
+			writes: func(t *testing.T, s3, ec2 *asyncexec.Job) {
+				fmt.Fprintln(s3.Stdout, "building")
+				fmt.Fprintln(ec2.Stderr, "error: oops")
+			},
+			want: "s3  | building\nec2 | error: oops\n",
+		},
+		{
+			name: "lines are buffered until complete",
+			writes: func(t *testing.T, s3, ec2 *asyncexec.Job) {
+				fmt.Fprint(s3.Stdout, "one ")
+				fmt.Fprint(ec2.Stdout, "first\nsecond\r\n\nthi")
+				fmt.Fprint(s3.Stdout, "two\n")
+			},
+			want: "ec2 | first\nec2 | second\nec2 |\ns3  | one two\nec2 | thi\n",
+		},
+		{
+			name:  "colored prefixes",
+			color: true,
+			writes: func(t *testing.T, s3, ec2 *asyncexec.Job) {
+				fmt.Fprintln(s3.Stdout, "a")
+				fmt.Fprintln(ec2.Stdout, "b")
+			},
+			want: "\x1b[36ms3  |\x1b[0m a\n\x1b[33mec2 |\x1b[0m b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			mux := asyncexec.NewMultiplexer(&out, asyncexec.WithColor(tt.color))
+			s3, err := mux.Job("s3")
+			require.NoError(t, err)
+			ec2, err := mux.Job("ec2")
+			require.NoError(t, err)
+
+			tt.writes(t, s3, ec2)
+			require.NoError(t, s3.Close())
+			require.NoError(t, ec2.Close())
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestMultiplexer_LineAtomic(t *testing.T) {
+	var out bytes.Buffer
+	mux := asyncexec.NewMultiplexer(&out)
+
+	var want []string
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		job, err := mux.Job(fmt.Sprintf("job-%d", i))
+		require.NoError(t, err)
+		for n := 0; n < 100; n++ {
+			want = append(want, fmt.Sprintf("job-%d | line %d of job %d", i, n, i))
+		}
+
+		wg.Add(1)
+		go func(i int, job *asyncexec.Job) {
+			defer wg.Done()
+			defer job.Close()
+			for n := 0; n < 100; n++ {
+				// split lines across several writes
+				fmt.Fprintf(job.Stdout, "line %d ", n)
+				fmt.Fprintf(job.Stdout, "of job %d\n", i)
+			}
+		}(i, job)
+	}
+	wg.Wait()
+
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	sort.Strings(got)
+	sort.Strings(want)
+	assert.Equal(t, want, got)
+}
+
+func TestMultiplexer_LogDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asyncexec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logDir := filepath.Join(dir, "logs")
+
+	var out bytes.Buffer
+	mux := asyncexec.NewMultiplexer(&out, asyncexec.WithColor(true), asyncexec.WithLogDirectory(logDir))
+	job, err := mux.Job("aws-controllers-k8s/s3")
+	require.NoError(t, err)
+
+	cmd := asyncexec.New(exec.Command("sh", "-c", "echo out; echo err >&2"), 1)
+	require.NoError(t, cmd.Run())
+	require.NoError(t, job.Stream(cmd))
+	require.NoError(t, cmd.Wait())
+	fmt.Fprint(job.Stdout, "partial")
+	require.NoError(t, job.Close())
+
+	logFile := asyncexec.LogFileName(job.Name())
+	assert.Regexp(t, `^aws-controllers-k8s_s3-[0-9a-f]{8}\.log$`, logFile)
+	b, err := ioutil.ReadFile(filepath.Join(logDir, logFile))
+	require.NoError(t, err)
+	lines := strings.Split(string(b), "\n")
+	sort.Strings(lines[:2])
+	// log files contain the raw output, without prefix nor color
+	assert.Equal(t, []string{"err", "out", "partial", ""}, lines)
+	assert.Contains(t, out.String(), "aws-controllers-k8s/s3 |\x1b[0m out\n")
+}
+
+func TestLogFileName(t *testing.T) {
+	assert.Equal(t, "s3-controller.log", asyncexec.LogFileName("s3-controller"))
+	// names differing only by replaced characters don't share a log file
+	assert.NotEqual(t, asyncexec.LogFileName("a/b"), asyncexec.LogFileName("a:b"))
+	assert.NotEqual(t, asyncexec.LogFileName("a_b"), asyncexec.LogFileName("a/b"))
+}
+
+func TestMultiplexer_LogFileCollision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asyncexec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mux := asyncexec.NewMultiplexer(ioutil.Discard, asyncexec.WithLogDirectory(dir))
+	job, err := mux.Job("s3")
+	require.NoError(t, err)
+	defer job.Close()
+	_, err = mux.Job("s3")
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+// +build !windows
+
+package asyncexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup configures the command to run in its own process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalProcessGroup sends a signal to the process group of p.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+	return syscall.Kill(-p.Pid, s)
+}
+
+// terminateProcessGroup sends SIGTERM to the process group of p.
+func terminateProcessGroup(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGTERM)
+}
+
+// killProcessGroup sends SIGKILL to the process group of p.
+func killProcessGroup(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGKILL)
+}
+
+// processGroupAlive returns true if a process of the process group of p is
+// still running.
+func processGroupAlive(p *os.Process) bool {
+	err := syscall.Kill(-p.Pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,42 @@
+// +build windows
+
+package asyncexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup configures the command to run in its own process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// signalProcessGroup sends a signal to p. Windows only supports killing
+// processes.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	if sig == syscall.SIGTERM {
+		return p.Kill()
+	}
+	return p.Signal(sig)
+}
+
+// terminateProcessGroup kills p. Windows doesn't support SIGTERM.
+func terminateProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+// killProcessGroup kills p.
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+// processGroupAlive returns false, Windows process groups are not tracked
+// once p exited.
+func processGroupAlive(p *os.Process) bool {
+	return false
+}
@@ -1,6 +1,7 @@
 package asyncexec
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,12 +10,18 @@ import (
 // StreamCommand executes a given command in a context directory and streams
 // the outputs to their according stdeout/stderr.
 func StreamCommand(workDir string, command string, args []string) error {
+	return StreamCommandContext(context.Background(), workDir, command, args)
+}
+
+// StreamCommandContext is like StreamCommand but stops the command when ctx
+// is done.
+func StreamCommandContext(ctx context.Context, workDir string, command string, args []string) error {
 	cmd := exec.Command(command, args...)
 	if workDir != "" {
 		cmd.Dir = workDir
 	}
 
-	acmd := New(cmd, 8)
+	acmd := NewWithContext(ctx, cmd, 8)
 	err := acmd.Run()
 	if err != nil {
 		return err
@@ -25,7 +32,7 @@ func StreamCommand(workDir string, command string, args []string) error {
 
 	go func() {
 		for b := range acmd.StdoutStream() {
-			_, err := os.Stdout.Write(b)
+			_, err := os.Stdout.Write(append(b, '\n'))
 			if err != nil {
 				msg := fmt.Sprintf("failed to write to Stdout: %v", err)
 				// should never happen, just panic.
@@ -36,7 +43,7 @@ func StreamCommand(workDir string, command string, args []string) error {
 	}()
 	go func() {
 		for b := range acmd.StderrStream() {
-			_, err := os.Stderr.Write(b)
+			_, err := os.Stderr.Write(append(b, '\n'))
 			if err != nil {
 				msg := fmt.Sprintf("failed to write to Stderr: %v", err)
 				// should never happen, just panic.
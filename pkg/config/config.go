@@ -15,6 +15,7 @@ package config
 
 import (
 	"io/ioutil"
+	"net/url"
 
 	"github.com/ghodss/yaml"
 )
@@ -36,6 +37,37 @@ type Config struct {
 	// RunConfig let specify the arguments and flags used to run a controller locally,
 	// without having to build it image or deploy it into a cluster.
 	RunConfig RunConfig `yaml:"run" json:"run"`
+	// Tools maps development tools to the semver constraints their versions must
+	// satisfy. For example {"go": ">=1.16", "kind": "0.11.x"}. Constraints declared
+	// in a .ackdev-tools.yaml file take precedence over this field.
+	Tools map[string]string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// Dependencies declares development tools in addition to the ones known by
+	// ackdev (go, kind, helm...). A dependency named after a built-in one overrides
+	// its settings.
+	Dependencies []DependencyConfig `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+}
+
+// DependencyConfig declares a development tool.
+type DependencyConfig struct {
+	// Name is the tool binary name.
+	Name string `yaml:"name" json:"name"`
+	// VersionArgs are the arguments passed to the binary to print its version.
+	// For example ["version", "--short"]. Tools that aren't built-in default
+	// to ["--version"].
+	VersionArgs []string `yaml:"versionArgs,omitempty" json:"versionArgs,omitempty"`
+	// VersionRegex is the regular expression used to find the version in the
+	// binary output. If it contains a group named 'version' (or any other group)
+	// only the group is kept. By default ackdev looks for the first semantic
+	// version in the output.
+	VersionRegex string `yaml:"versionRegex,omitempty" json:"versionRegex,omitempty"`
+	// VersionJSONPath is the JSONPath template used to find the version in the
+	// binary output, for tools printing their version in JSON. For example
+	// '{.clientVersion.gitVersion}'. It cannot be combined with VersionRegex.
+	VersionJSONPath string `yaml:"versionJSONPath,omitempty" json:"versionJSONPath,omitempty"`
+	// Optional dependencies are reported but don't make the compliance check fail
+	// when they are missing. If it's not specified, built-in dependencies keep
+	// their setting and other dependencies are required.
+	Optional *bool `yaml:"optional,omitempty" json:"optional,omitempty"`
 }
 
 // RepositoriesConfig represent repositories that are be managed by ackdev.
@@ -45,6 +77,28 @@ type RepositoriesConfig struct {
 	Core []string `yaml:"core" json:"core"`
 	// Services is the list of service controllers managed by ackdev.
 	Services []string `yaml:"services" json:"services"`
+	// Overrides contains per-repository overrides of the upstream repository and
+	// of the local and fork names, indexed by the repository name used in Core or
+	// Services (e.g s3). Use them for repositories that don't follow the ACK
+	// naming rules, or that are hosted outside of the upstream organisation.
+	Overrides map[string]RepositoryOverrides `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+}
+
+// RepositoryOverrides overrides the names ackdev derives from a repository name.
+// Empty fields keep their default values.
+type RepositoryOverrides struct {
+	// UpstreamOwner is the Github user or organisation owning the upstream
+	// repository. It overrides Github.UpstreamOrg.
+	UpstreamOwner string `yaml:"upstreamOwner,omitempty" json:"upstreamOwner,omitempty"`
+	// UpstreamName is the name of the upstream repository. By default it's the
+	// repository name, with a '-controller' suffix for service controllers.
+	UpstreamName string `yaml:"upstreamName,omitempty" json:"upstreamName,omitempty"`
+	// Directory is the local directory of the repository. A relative path is
+	// relative to RootDirectory. By default it's the upstream repository name.
+	Directory string `yaml:"directory,omitempty" json:"directory,omitempty"`
+	// ForkName is the name of the personal fork. By default it's the upstream
+	// repository name prefixed with Github.ForkPrefix.
+	ForkName string `yaml:"forkName,omitempty" json:"forkName,omitempty"`
 }
 
 // GithubConfig represents the Github information needed to personal forks.
@@ -59,12 +113,73 @@ type GithubConfig struct {
 	// For example if ForkPrefix is 'ack-', ackdev will fork code-generator repository
 	// and rename to 'ack-code-generator.
 	ForkPrefix string `yaml:"forkPrefix" json:"forkPrefix"`
+	// UpstreamOrg is the Github organisation owning the upstream repositories.
+	// If it's not specified ackdev will use aws-controllers-k8s.
+	UpstreamOrg string `yaml:"upstreamOrg,omitempty" json:"upstreamOrg,omitempty"`
+	// BaseURL is the Github API endpoint. Set it to use Github Enterprise or any
+	// Github compatible API, for example https://github.example.com/api/v3/. If
+	// it's not specified ackdev will use https://api.github.com/
+	BaseURL string `yaml:"baseURL,omitempty" json:"baseURL,omitempty"`
+	// UploadURL is the Github uploads API endpoint. If it's not specified ackdev
+	// will use BaseURL.
+	UploadURL string `yaml:"uploadURL,omitempty" json:"uploadURL,omitempty"`
+	// GitHost is the host of the git remotes, for example github.example.com.
+	// It can contain a port, for example github.example.com:2222. If it's not
+	// specified ackdev will use the host of BaseURL, or github.com.
+	GitHost string `yaml:"gitHost,omitempty" json:"gitHost,omitempty"`
+}
+
+// DefaultGitHost is the host of the git remotes when neither GitHost nor BaseURL
+// are specified.
+const DefaultGitHost = "github.com"
+
+// Host returns the host of the HTTPS git remotes, including the BaseURL port if
+// any.
+func (c *GithubConfig) Host() string {
+	if c.GitHost != "" {
+		return c.GitHost
+	}
+	if u := c.baseURL(); u != nil {
+		return u.Host
+	}
+	return DefaultGitHost
+}
+
+// SSHHost returns the host of the SSH git remotes. The BaseURL port is an HTTPS
+// port and is never used for SSH, only GitHost can specify an SSH port.
+func (c *GithubConfig) SSHHost() string {
+	if c.GitHost != "" {
+		return c.GitHost
+	}
+	if u := c.baseURL(); u != nil {
+		return u.Hostname()
+	}
+	return DefaultGitHost
+}
+
+// baseURL returns the parsed BaseURL, or nil if it's not set, invalid or
+// points to api.github.com.
+func (c *GithubConfig) baseURL() *url.URL {
+	if c.BaseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || u.Hostname() == "" || u.Hostname() == "api.github.com" {
+		return nil
+	}
+	return u
 }
 
 // Git contains information used by ackdev to manage local git repositories.
 type GitConfig struct {
 	// SSHKeyPath is the full path the SSH key used to clone Github repositories.
 	SSHKeyPath string `yaml:"sshKeyPath" json:"sshKeyPath"`
+	// SSHAgent tells ackdev to authenticate using the keys of the running ssh-agent
+	// (SSH_AUTH_SOCK). When it's set SSHKeyPath is ignored.
+	SSHAgent bool `yaml:"sshAgent" json:"sshAgent"`
+	// KnownHostsPath is the path to the known_hosts file used to verify Github host
+	// keys. If it's not specified ackdev will use $HOME/.ssh/known_hosts
+	KnownHostsPath string `yaml:"knownHostsPath" json:"knownHostsPath"`
 }
 
 // RunConfig contains flags and arguments passed to service controllers binaries when
@@ -73,6 +188,36 @@ type RunConfig struct {
 	// Flags is the map of flags/values passed to the controller binaries. For example
 	// to pass --aws-region=us-west-1 you'll need to set Flags to {"aws-region","us-west-1"}
 	Flags map[string]string `yaml:"flags" json:"flags"`
+	// Env is the map of environment variables set for the controller binaries, in
+	// addition to the ackdev environment. For example {"AWS_PROFILE": "dev"}.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	// WorkingDirectory is the directory the controller binaries are executed in. A
+	// relative path is relative to the service repository, which is the default.
+	WorkingDirectory string `yaml:"workingDirectory,omitempty" json:"workingDirectory,omitempty"`
+	// Services contains per-service overrides, indexed by service name (e.g s3).
+	Services map[string]RunOverrides `yaml:"services,omitempty" json:"services,omitempty"`
+	// Profiles contains named sets of overrides (e.g dev, localstack...) selected
+	// using 'ackdev run --profile'.
+	Profiles map[string]RunProfile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+}
+
+// RunOverrides contains flags, environment variables and working directory
+// overriding the ones of the run configuration.
+type RunOverrides struct {
+	// Flags is the map of flags/values merged with the configured ones.
+	Flags map[string]string `yaml:"flags,omitempty" json:"flags,omitempty"`
+	// Env is the map of environment variables merged with the configured ones.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	// WorkingDirectory overrides the configured working directory when it's not
+	// empty.
+	WorkingDirectory string `yaml:"workingDirectory,omitempty" json:"workingDirectory,omitempty"`
+}
+
+// RunProfile is a named set of overrides applied on top of the run configuration.
+type RunProfile struct {
+	RunOverrides
+	// Services contains per-service overrides of the profile, indexed by service name.
+	Services map[string]RunOverrides `yaml:"services,omitempty" json:"services,omitempty"`
 }
 
 // DefaultConfig is the default configuration used to generated ackdev config
@@ -0,0 +1,77 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubConfig_Host(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      GithubConfig
+		wantHost    string
+		wantSSHHost string
+	}{
+		{
+			name:        "default host",
+			wantHost:    "github.com",
+			wantSSHHost: "github.com",
+		},
+		{
+			name:        "api.github.com base URL",
+			config:      GithubConfig{BaseURL: "https://api.github.com/"},
+			wantHost:    "github.com",
+			wantSSHHost: "github.com",
+		},
+		{
+			name:        "enterprise base URL",
+			config:      GithubConfig{BaseURL: "https://ghe.example.com/api/v3/"},
+			wantHost:    "ghe.example.com",
+			wantSSHHost: "ghe.example.com",
+		},
+		{
+			name:        "enterprise base URL with a port",
+			config:      GithubConfig{BaseURL: "https://ghe.example.com:8443/api/v3/"},
+			wantHost:    "ghe.example.com:8443",
+			wantSSHHost: "ghe.example.com",
+		},
+		{
+			name:        "git host",
+			config:      GithubConfig{BaseURL: "https://api.ghe.example.com:8443/", GitHost: "git.example.com"},
+			wantHost:    "git.example.com",
+			wantSSHHost: "git.example.com",
+		},
+		{
+			name:        "git host with a port",
+			config:      GithubConfig{GitHost: "git.example.com:2222"},
+			wantHost:    "git.example.com:2222",
+			wantSSHHost: "git.example.com:2222",
+		},
+		{
+			name:        "invalid base URL",
+			config:      GithubConfig{BaseURL: "ghe.example.com"},
+			wantHost:    "github.com",
+			wantSSHHost: "github.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantHost, tt.config.Host())
+			assert.Equal(t, tt.wantSSHHost, tt.config.SSHHost())
+		})
+	}
+}
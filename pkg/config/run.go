@@ -0,0 +1,88 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrUnknownProfile is returned when selecting a run profile that isn't
+	// configured.
+	ErrUnknownProfile = errors.New("unknown run profile")
+)
+
+// EffectiveRunConfig is the run configuration of a service once the service and
+// profile overrides are applied.
+type EffectiveRunConfig struct {
+	// Service is the service name.
+	Service string `yaml:"service" json:"service"`
+	// Profile is the applied profile name, if any.
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+	// Flags is the merged map of flags/values passed to the controller binary.
+	Flags map[string]string `yaml:"flags" json:"flags"`
+	// Env is the merged map of environment variables set for the controller binary.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	// WorkingDirectory is the directory the controller binary is executed in.
+	WorkingDirectory string `yaml:"workingDirectory,omitempty" json:"workingDirectory,omitempty"`
+}
+
+// Effective returns the run configuration of a service. The global flags and
+// environment variables are overridden, in order, by the service overrides,
+// the profile ones and finally the profile service overrides. profile can be
+// empty.
+func (c *RunConfig) Effective(service, profile string) (*EffectiveRunConfig, error) {
+	overrides := []RunOverrides{
+		{Flags: c.Flags, Env: c.Env, WorkingDirectory: c.WorkingDirectory},
+		c.Services[service],
+	}
+	if profile != "" {
+		p, ok := c.Profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("%w %q, known profiles are: %v", ErrUnknownProfile, profile, c.ProfileNames())
+		}
+		overrides = append(overrides, p.RunOverrides, p.Services[service])
+	}
+
+	effective := &EffectiveRunConfig{
+		Service: service,
+		Profile: profile,
+		Flags:   map[string]string{},
+		Env:     map[string]string{},
+	}
+	for _, o := range overrides {
+		for key, value := range o.Flags {
+			effective.Flags[key] = value
+		}
+		for key, value := range o.Env {
+			effective.Env[key] = value
+		}
+		if o.WorkingDirectory != "" {
+			effective.WorkingDirectory = o.WorkingDirectory
+		}
+	}
+	return effective, nil
+}
+
+// ProfileNames returns the sorted names of the configured run profiles.
+func (c *RunConfig) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
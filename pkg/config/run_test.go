@@ -0,0 +1,167 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConfig_Effective(t *testing.T) {
+	runConfig := &RunConfig{
+		Flags: map[string]string{
+			"aws-region":   "us-west-2",
+			"log-level":    "info",
+			"enable-debug": "",
+		},
+		Env:              map[string]string{"AWS_PROFILE": "dev", "KUBECONFIG": "~/.kube/config"},
+		WorkingDirectory: "/tmp/global",
+		Services: map[string]RunOverrides{
+			"s3": {
+				Flags:            map[string]string{"log-level": "debug"},
+				Env:              map[string]string{"AWS_PROFILE": "s3"},
+				WorkingDirectory: "/tmp/s3",
+			},
+			"ecr": {
+				Flags: map[string]string{"aws-region": "eu-west-1"},
+			},
+		},
+		Profiles: map[string]RunProfile{
+			"localstack": {
+				RunOverrides: RunOverrides{
+					Flags: map[string]string{"aws-endpoint-url": "http://localhost:4566", "aws-region": "us-east-1"},
+					Env:   map[string]string{"AWS_PROFILE": "localstack"},
+				},
+				Services: map[string]RunOverrides{
+					"s3": {
+						Flags:            map[string]string{"aws-region": "us-east-2"},
+						WorkingDirectory: "/tmp/localstack-s3",
+					},
+				},
+			},
+			"prod": {},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		service string
+		profile string
+		want    *EffectiveRunConfig
+	}{
+		{
+			name:    "global configuration",
+			service: "sns",
+			want: &EffectiveRunConfig{
+				Service:          "sns",
+				Flags:            map[string]string{"aws-region": "us-west-2", "log-level": "info", "enable-debug": ""},
+				Env:              map[string]string{"AWS_PROFILE": "dev", "KUBECONFIG": "~/.kube/config"},
+				WorkingDirectory: "/tmp/global",
+			},
+		},
+		{
+			name:    "service overrides the global configuration",
+			service: "s3",
+			want: &EffectiveRunConfig{
+				Service:          "s3",
+				Flags:            map[string]string{"aws-region": "us-west-2", "log-level": "debug", "enable-debug": ""},
+				Env:              map[string]string{"AWS_PROFILE": "s3", "KUBECONFIG": "~/.kube/config"},
+				WorkingDirectory: "/tmp/s3",
+			},
+		},
+		{
+			name:    "profile overrides the service",
+			service: "ecr",
+			profile: "localstack",
+			want: &EffectiveRunConfig{
+				Service: "ecr",
+				Profile: "localstack",
+				Flags: map[string]string{
+					"aws-region":       "us-east-1",
+					"aws-endpoint-url": "http://localhost:4566",
+					"log-level":        "info",
+					"enable-debug":     "",
+				},
+				Env:              map[string]string{"AWS_PROFILE": "localstack", "KUBECONFIG": "~/.kube/config"},
+				WorkingDirectory: "/tmp/global",
+			},
+		},
+		{
+			name:    "profile service overrides the profile",
+			service: "s3",
+			profile: "localstack",
+			want: &EffectiveRunConfig{
+				Service: "s3",
+				Profile: "localstack",
+				Flags: map[string]string{
+					"aws-region":       "us-east-2",
+					"aws-endpoint-url": "http://localhost:4566",
+					"log-level":        "debug",
+					"enable-debug":     "",
+				},
+				Env:              map[string]string{"AWS_PROFILE": "localstack", "KUBECONFIG": "~/.kube/config"},
+				WorkingDirectory: "/tmp/localstack-s3",
+			},
+		},
+		{
+			name:    "empty profile",
+			service: "ecr",
+			profile: "prod",
+			want: &EffectiveRunConfig{
+				Service:          "ecr",
+				Profile:          "prod",
+				Flags:            map[string]string{"aws-region": "eu-west-1", "log-level": "info", "enable-debug": ""},
+				Env:              map[string]string{"AWS_PROFILE": "dev", "KUBECONFIG": "~/.kube/config"},
+				WorkingDirectory: "/tmp/global",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runConfig.Effective(tt.service, tt.profile)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// the configuration is never modified
+	assert.Equal(t, "info", runConfig.Flags["log-level"])
+	assert.Equal(t, "dev", runConfig.Env["AWS_PROFILE"])
+}
+
+func TestRunConfig_Effective_emptyConfig(t *testing.T) {
+	got, err := (&RunConfig{}).Effective("s3", "")
+	require.NoError(t, err)
+	assert.Equal(t, &EffectiveRunConfig{
+		Service: "s3",
+		Flags:   map[string]string{},
+		Env:     map[string]string{},
+	}, got)
+}
+
+func TestRunConfig_Effective_unknownProfile(t *testing.T) {
+	runConfig := &RunConfig{
+		Profiles: map[string]RunProfile{"localstack": {}, "dev": {}},
+	}
+	_, err := runConfig.Effective("s3", "prod")
+	assert.True(t, errors.Is(err, ErrUnknownProfile), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), `"prod"`)
+	assert.Contains(t, err.Error(), "[dev localstack]")
+
+	_, err = (&RunConfig{}).Effective("s3", "prod")
+	assert.True(t, errors.Is(err, ErrUnknownProfile), "unexpected error: %v", err)
+}
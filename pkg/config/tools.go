@@ -0,0 +1,99 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// ToolsFileName is the name of the files declaring the development tools
+	// versions of a project. These files are meant to be checked in with the
+	// project sources.
+	ToolsFileName = ".ackdev-tools.yaml"
+)
+
+// ToolsFile declares the versions of the development tools needed to work
+// on a project.
+type ToolsFile struct {
+	// Tools maps development tools to the semver constraints their versions
+	// must satisfy.
+	Tools map[string]string `yaml:"tools" json:"tools"`
+}
+
+// LoadToolsFile reads a tools file and returns the declared constraints.
+func LoadToolsFile(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tf ToolsFile
+	err = yaml.Unmarshal(content, &tf)
+	if err != nil {
+		return nil, err
+	}
+	return tf.Tools, nil
+}
+
+// FindToolsFile looks for a tools file in dir and its parent directories.
+// It returns an empty string if no file is found.
+func FindToolsFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, ToolsFileName)
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ToolConstraints returns the tools version constraints declared in the
+// configuration, overridden by the ones declared in the tools file at path.
+// path can be empty.
+func (c *Config) ToolConstraints(path string) (map[string]string, error) {
+	constraints := make(map[string]string, len(c.Tools))
+	for name, constraint := range c.Tools {
+		constraints[name] = constraint
+	}
+	if path == "" {
+		return constraints, nil
+	}
+
+	fileConstraints, err := LoadToolsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for name, constraint := range fileConstraints {
+		constraints[name] = constraint
+	}
+	return constraints, nil
+}
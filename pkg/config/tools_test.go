@@ -0,0 +1,109 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindToolsFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "ackdev-tools")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	// resolve symbolic links, e.g /tmp on macOS
+	root, err = filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+
+	project := filepath.Join(root, "project")
+	nested := filepath.Join(project, "pkg", "controller")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	toolsFile := filepath.Join(project, ToolsFileName)
+	require.NoError(t, ioutil.WriteFile(toolsFile, []byte("tools:\n  go: '>=1.16'\n"), 0644))
+
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{"file in the directory", project, toolsFile},
+		{"file in a parent directory", nested, toolsFile},
+		{"no file", root, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindToolsFile(tt.dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfig_ToolConstraints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-tools")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	toolsFile := filepath.Join(dir, ToolsFileName)
+	require.NoError(t, ioutil.WriteFile(toolsFile, []byte("tools:\n  go: '>=1.16'\n  helm: 3.x\n"), 0644))
+	malformedFile := filepath.Join(dir, "malformed.yaml")
+	require.NoError(t, ioutil.WriteFile(malformedFile, []byte("tools: [go\n"), 0644))
+
+	cfg := &Config{Tools: map[string]string{"go": ">=1.15", "kind": "0.11.x"}}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "configuration only",
+			want: map[string]string{"go": ">=1.15", "kind": "0.11.x"},
+		},
+		{
+			name: "tools file overrides the configuration",
+			path: toolsFile,
+			want: map[string]string{"go": ">=1.16", "kind": "0.11.x", "helm": "3.x"},
+		},
+		{
+			name:    "malformed tools file",
+			path:    malformedFile,
+			wantErr: true,
+		},
+		{
+			name:    "missing tools file",
+			path:    filepath.Join(dir, "missing.yaml"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.ToolConstraints(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	// the configuration is never modified
+	assert.Equal(t, ">=1.15", cfg.Tools["go"])
+}
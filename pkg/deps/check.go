@@ -0,0 +1,129 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// CheckResult is the result of a dependency compliance check.
+type CheckResult struct {
+	// Dependency is the checked dependency
+	Dependency Dependency
+	// Path is the dependency binary path. It is empty if the binary
+	// cannot be found.
+	Path string
+	// Version is the normalized version of the dependency binary. It is
+	// empty if the version cannot be determined.
+	Version string
+	// Constraint is the version constraint the dependency must satisfy.
+	// It is nil if the dependency isn't pinned.
+	Constraint *Constraint
+	// Status is the dependency compliance status
+	Status Status
+	// Stderr is the error output of the dependency version command
+	Stderr string
+	// Err is the error that prevented the version check, if any
+	Err error
+}
+
+// Compliant returns true if the dependency is installed and satisfies
+// its version constraint, or if it's an optional dependency that isn't
+// installed.
+func (r *CheckResult) Compliant() bool {
+	return r.Status == StatusOK || (r.Status == StatusMissing && r.Dependency.Optional)
+}
+
+// Check looks up a dependency binary and version, and checks the version
+// against constraint. constraint can be nil, in which case any installed
+// version is compliant. The version command is killed when ctx is done.
+func Check(ctx context.Context, dep Dependency, constraint *Constraint) *CheckResult {
+	result := &CheckResult{
+		Dependency: dep,
+		Constraint: constraint,
+	}
+
+	path, err := dep.BinPath()
+	if err != nil {
+		result.Status = StatusMissing
+		return result
+	}
+	result.Path = path
+
+	out, err := dep.probe(ctx)
+	if out != nil {
+		result.Stderr = string(out.stderr)
+	}
+	switch {
+	case err == ErrProbeTimeout:
+		result.Status = StatusTimeout
+		result.Err = err
+		return result
+	case err != nil:
+		result.Status = StatusFailed
+		result.Err = err
+		return result
+	}
+
+	result.Status = StatusOK
+	version, err := dep.extractVersion(out)
+	if err != nil {
+		if constraint != nil {
+			result.Status = StatusUnknown
+			result.Err = err
+		}
+		return result
+	}
+	result.Version = version.String()
+
+	if constraint != nil {
+		result.Status = constraint.Check(version)
+	}
+	return result
+}
+
+// ParseConstraints parses a map of dependency names to version constraints,
+// such as {"go": ">=1.16", "kind": "0.11.x"}. It returns an error if a
+// dependency isn't part of dependencies.
+func ParseConstraints(
+	dependencies []Dependency,
+	constraints map[string]string,
+) (map[string]*Constraint, error) {
+	known := make(map[string]bool, len(dependencies))
+	for _, dep := range dependencies {
+		known[dep.BinaryName] = true
+	}
+
+	// sort names to return errors deterministically
+	names := make([]string, 0, len(constraints))
+	for name := range constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsed := make(map[string]*Constraint, len(constraints))
+	for _, name := range names {
+		if !known[name] {
+			return nil, fmt.Errorf("cannot pin version of unknown dependency %s", name)
+		}
+		c, err := ParseConstraint(constraints[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		parsed[name] = c
+	}
+	return parsed, nil
+}
@@ -0,0 +1,259 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the compliance status of a dependency.
+type Status string
+
+const (
+	// StatusOK means that the dependency is installed and satisfies its
+	// version constraint, if any.
+	StatusOK Status = "OK"
+	// StatusTooOld means that the installed version is lower than the
+	// versions allowed by the constraint.
+	StatusTooOld Status = "TOO OLD"
+	// StatusTooNew means that the installed version is greater than the
+	// versions allowed by the constraint.
+	StatusTooNew Status = "TOO NEW"
+	// StatusMissing means that the dependency binary cannot be found.
+	StatusMissing Status = "MISSING"
+	// StatusUnknown means that the dependency has a version constraint but
+	// its version cannot be determined.
+	StatusUnknown Status = "UNKNOWN"
+	// StatusTimeout means that the dependency version command didn't
+	// complete in time.
+	StatusTimeout Status = "TIMEOUT"
+	// StatusFailed means that the dependency version command failed.
+	StatusFailed Status = "FAILED"
+)
+
+// Constraint is a set of conditions a dependency version must satisfy.
+//
+// A constraint is a comma or space separated list of comparisons, all of
+// them must be satisfied. Operators can be separated from their version by
+// spaces, e.g '>= 1.16'. Supported comparisons are:
+//   - '1.2.3' or '=1.2.3': exact version
+//   - '1.2', '1.2.x' or '=1.2.*': any 1.2 patch version
+//   - '>1.2.3', '>=1.2.3', '<1.2.3', '<=1.2.3': version ranges
+//   - '~1.2.3': patch updates only (>=1.2.3 <1.3.0)
+//   - '^1.2.3': minor and patch updates (>=1.2.3 <2.0.0)
+//
+// The 'v' prefix is optional.
+type Constraint struct {
+	raw         string
+	comparisons []comparison
+}
+
+// constraintOperators are the operators a constraint term can start with.
+// Longer operators come first.
+var constraintOperators = []string{">=", "<=", "==", ">", "<", "=", "~", "^"}
+
+// comparison compares a version with a bound, using one of the operators
+// =, <, <=, > or >=.
+type comparison struct {
+	operator string
+	bound    Version
+}
+
+// ParseConstraint parses a version constraint such as '>=1.16' or '0.11.x'.
+func ParseConstraint(s string) (*Constraint, error) {
+	terms := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("invalid version constraint %q: empty constraint", s)
+	}
+
+	c := &Constraint{raw: strings.TrimSpace(s)}
+	for i := 0; i < len(terms); i++ {
+		term := terms[i]
+		// attach a bare operator to the version following it
+		if isConstraintOperator(term) && i+1 < len(terms) {
+			i++
+			term += terms[i]
+		}
+		comparisons, err := parseComparisonTerm(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %v", s, err)
+		}
+		c.comparisons = append(c.comparisons, comparisons...)
+	}
+	return c, nil
+}
+
+// isConstraintOperator returns true if s is a constraint operator.
+func isConstraintOperator(s string) bool {
+	for _, op := range constraintOperators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+// parseComparisonTerm parses a single term of a constraint and expands it
+// into simple comparisons.
+func parseComparisonTerm(term string) ([]comparison, error) {
+	operator := ""
+	for _, op := range constraintOperators {
+		if strings.HasPrefix(term, op) {
+			operator = op
+			break
+		}
+	}
+	pattern := strings.TrimPrefix(term[len(operator):], "v")
+	if pattern == "" {
+		return nil, fmt.Errorf("missing version after %q", operator)
+	}
+
+	// Split the version pattern into its numeric components and keep track
+	// of the number of components that were specified. Wildcards end the
+	// pattern.
+	var prerelease string
+	if i := strings.IndexAny(pattern, "-+"); i >= 0 {
+		if pattern[i] == '-' {
+			prerelease = strings.SplitN(pattern[i+1:], "+", 2)[0]
+		}
+		pattern = pattern[:i]
+	}
+	parts := strings.Split(pattern, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("%q has too many components", term)
+	}
+	numbers := make([]int, 3)
+	specified := 0
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("%q wildcard must be the last component", term)
+			}
+			break
+		}
+		n, err := parseVersionNumber(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", term, err)
+		}
+		numbers[i] = n
+		specified++
+	}
+	if prerelease != "" && specified != 3 {
+		return nil, fmt.Errorf("%q: pre-release requires a full version", term)
+	}
+
+	lower := Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2], Prerelease: prerelease}
+	// upper is the first version that doesn't match a partial version,
+	// e.g 1.3.0 for 1.2.x
+	upper := lower
+	upper.Prerelease = ""
+	switch specified {
+	case 0:
+		if operator == "" || operator == "=" || operator == "==" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%q wildcard cannot be used with %q", term, operator)
+	case 1:
+		upper = Version{Major: lower.Major + 1}
+	case 2:
+		upper = Version{Major: lower.Major, Minor: lower.Minor + 1}
+	}
+
+	switch operator {
+	case "", "=", "==":
+		if specified == 3 {
+			return []comparison{{"=", lower}}, nil
+		}
+		return []comparison{{">=", lower}, {"<", upper}}, nil
+	case ">=", "<":
+		return []comparison{{operator, lower}}, nil
+	case ">":
+		if specified == 3 {
+			return []comparison{{">", lower}}, nil
+		}
+		return []comparison{{">=", upper}}, nil
+	case "<=":
+		if specified == 3 {
+			return []comparison{{"<=", lower}}, nil
+		}
+		return []comparison{{"<", upper}}, nil
+	case "~":
+		if specified == 1 {
+			return []comparison{{">=", lower}, {"<", upper}}, nil
+		}
+		return []comparison{{">=", lower}, {"<", Version{Major: lower.Major, Minor: lower.Minor + 1}}}, nil
+	case "^":
+		switch {
+		case lower.Major > 0 || specified == 1:
+			upper = Version{Major: lower.Major + 1}
+		case lower.Minor > 0 || specified == 2:
+			upper = Version{Minor: lower.Minor + 1}
+		default:
+			upper = Version{Patch: lower.Patch + 1}
+		}
+		return []comparison{{">=", lower}, {"<", upper}}, nil
+	}
+	return nil, fmt.Errorf("unsupported operator %q", operator)
+}
+
+// String returns the constraint as it was written.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Exact returns the version allowed by the constraint if it pins an exact
+// version.
+func (c *Constraint) Exact() (Version, bool) {
+	if len(c.comparisons) != 1 || c.comparisons[0].operator != "=" {
+		return Version{}, false
+	}
+	return c.comparisons[0].bound, true
+}
+
+// Check returns StatusOK if v satisfies the constraint, StatusTooOld if v
+// is lower than the allowed versions and StatusTooNew if it is greater.
+func (c *Constraint) Check(v Version) Status {
+	for _, cmp := range c.comparisons {
+		result := v.Compare(cmp.bound)
+		switch cmp.operator {
+		case "=":
+			if result < 0 {
+				return StatusTooOld
+			}
+			if result > 0 {
+				return StatusTooNew
+			}
+		case ">":
+			if result <= 0 {
+				return StatusTooOld
+			}
+		case ">=":
+			if result < 0 {
+				return StatusTooOld
+			}
+		case "<":
+			if result >= 0 {
+				return StatusTooNew
+			}
+		case "<=":
+			if result > 0 {
+				return StatusTooNew
+			}
+		}
+	}
+	return StatusOK
+}
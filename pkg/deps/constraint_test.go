@@ -0,0 +1,117 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       Status
+	}{
+		{">=1.16", "1.16.0", StatusOK},
+		{">=1.16", "1.17.3", StatusOK},
+		{">=1.16", "1.15.8", StatusTooOld},
+		{">1.16", "1.16.5", StatusTooOld},
+		{">1.16", "1.17.0", StatusOK},
+		{">1.16.0", "1.16.1", StatusOK},
+		{"<1.17", "1.16.9", StatusOK},
+		{"<1.17", "1.17.0", StatusTooNew},
+		{"<=1.17", "1.17.2", StatusOK},
+		{"<=1.17", "1.18.0", StatusTooNew},
+		{"<=1.17.0", "1.17.2", StatusTooNew},
+		{"0.11.x", "0.11.1", StatusOK},
+		{"0.11.x", "v0.10.0", StatusTooOld},
+		{"0.11.x", "0.12.0", StatusTooNew},
+		{"=0.11.*", "0.11.0", StatusOK},
+		{"0.11", "0.11.3", StatusOK},
+		{"1.x", "1.20.0", StatusOK},
+		{"1.x", "2.0.0", StatusTooNew},
+		{"*", "42.0.0", StatusOK},
+		{"v0.4.0", "v0.4.0", StatusOK},
+		{"v0.4.0", "v0.4.1", StatusTooNew},
+		{"==v0.4.0", "v0.3.9", StatusTooOld},
+		{"~1.2.3", "1.2.9", StatusOK},
+		{"~1.2.3", "1.3.0", StatusTooNew},
+		{"~1.2.3", "1.2.2", StatusTooOld},
+		{"^1.2.3", "1.9.0", StatusOK},
+		{"^1.2.3", "2.0.0", StatusTooNew},
+		{"^0.4.1", "0.4.9", StatusOK},
+		{"^0.4.1", "0.5.0", StatusTooNew},
+		{"^0.0.3", "0.0.4", StatusTooNew},
+		{">=1.16, <1.18", "1.17.1", StatusOK},
+		{">=1.16 <1.18", "1.18.0", StatusTooNew},
+		{">= 1.16", "1.16.0", StatusOK},
+		{">= 1.16", "1.15.8", StatusTooOld},
+		{">= 1.16, < 1.18", "1.18.0", StatusTooNew},
+		{">= 1.16 < 1.18", "1.17.1", StatusOK},
+		{"~ 1.2.3", "1.3.0", StatusTooNew},
+		{"= v0.4.0", "v0.4.0", StatusOK},
+		{">=1.16,<1.18", "1.15.0", StatusTooOld},
+		{"1.0.0-rc.1", "1.0.0-rc.1", StatusOK},
+		{">=1.0.0", "1.0.0-rc.1", StatusTooOld},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		require.NoError(t, err, tt.constraint)
+		v, err := ParseVersion(tt.version)
+		require.NoError(t, err, tt.version)
+		assert.Equal(t, tt.want, c.Check(v), "%s %s", tt.constraint, tt.version)
+	}
+}
+
+func TestParseConstraint_errors(t *testing.T) {
+	tests := []struct {
+		constraint string
+		wantErr    string
+	}{
+		{"", `invalid version constraint "": empty constraint`},
+		{">=", `invalid version constraint ">=": missing version after ">="`},
+		{"<1.18 >=", `invalid version constraint "<1.18 >=": missing version after ">="`},
+		{">= >=1.16", `invalid version constraint ">= >=1.16": ">=>=1.16": ">=1" is not a number`},
+		{"1.x.3", `invalid version constraint "1.x.3": "1.x.3" wildcard must be the last component`},
+		{">=x", `invalid version constraint ">=x": ">=x" wildcard cannot be used with ">="`},
+		{"1.2.3.4", `invalid version constraint "1.2.3.4": "1.2.3.4" has too many components`},
+		{"!=1.2", `invalid version constraint "!=1.2": "!=1.2": "!=1" is not a number`},
+		{"1.2-rc.1", `invalid version constraint "1.2-rc.1": "1.2-rc.1": pre-release requires a full version`},
+	}
+	for _, tt := range tests {
+		_, err := ParseConstraint(tt.constraint)
+		assert.EqualError(t, err, tt.wantErr, tt.constraint)
+	}
+}
+
+func TestParseConstraints(t *testing.T) {
+	dependencies := []Dependency{{BinaryName: "go"}, {BinaryName: "kind"}}
+
+	constraints, err := ParseConstraints(dependencies, map[string]string{
+		"go":   ">=1.16",
+		"kind": "0.11.x",
+	})
+	require.NoError(t, err)
+	assert.Len(t, constraints, 2)
+	assert.Equal(t, ">=1.16", constraints["go"].String())
+
+	_, err = ParseConstraints(dependencies, map[string]string{"helm": "3.x"})
+	assert.EqualError(t, err, "cannot pin version of unknown dependency helm")
+
+	_, err = ParseConstraints(dependencies, map[string]string{"go": ">=x"})
+	assert.EqualError(t, err, `go: invalid version constraint ">=x": ">=x" wildcard cannot be used with ">="`)
+}
@@ -14,45 +14,93 @@
 package deps
 
 import (
+	"context"
 	"errors"
 	"os/exec"
 	"regexp"
+	"strings"
 )
 
 var (
 	ErrorVersionNotFound = errors.New("version not found in output")
+
+	// versionRegex matches semantic versions delimited by non alphanumeric
+	// characters
+	versionRegex = regexp.MustCompile(`(?:^|[^0-9A-Za-z]|go)` +
+		`(v?[0-9]+(?:\.[0-9]+)?(?:\.[0-9]+)?` +
+		`(?:-[0-9A-Za-z\-]+(?:\.[0-9A-Za-z\-]+)*)?` +
+		`(?:\+[0-9A-Za-z\-]+(?:\.[0-9A-Za-z\-]+)*)?)` +
+		`(?:$|[^0-9A-Za-z])`)
 )
 
 var (
 	// DevelopmentTools is the list of ACK development tools
 	DevelopmentTools = []Dependency{
+		// go is a toolchain rather than a single binary and isn't installed
+		// by ackdev
 		{
-			BinaryName:     "go",
-			GetVersionArgs: []string{"version"},
+			BinaryName:       "go",
+			GetVersionArgs:   []string{"version"},
+			VersionExtractor: mustRegexVersionExtractor(`go version go(?P<version>\S+)`),
 		},
 		{
-			BinaryName:     "kind",
-			GetVersionArgs: []string{"--version"},
+			BinaryName:       "kind",
+			GetVersionArgs:   []string{"--version"},
+			VersionExtractor: mustRegexVersionExtractor(`kind (?:version )?(?P<version>v?[0-9]\S*)`),
+			Release: &Release{
+				DefaultVersion: "0.11.1",
+				URL:            "https://github.com/kubernetes-sigs/kind/releases/download/v{{.Version}}/kind-{{.OS}}-{{.Arch}}",
+				ChecksumURL:    "https://github.com/kubernetes-sigs/kind/releases/download/v{{.Version}}/kind-{{.OS}}-{{.Arch}}.sha256sum",
+			},
 		},
 		{
-			BinaryName:     "helm",
-			GetVersionArgs: []string{"version", "--short"},
+			BinaryName:       "helm",
+			GetVersionArgs:   []string{"version", "--short"},
+			VersionExtractor: VersionExtractorFunc(extractHelmVersion),
+			Release: &Release{
+				DefaultVersion: "3.6.0",
+				URL:            "https://get.helm.sh/helm-v{{.Version}}-{{.OS}}-{{.Arch}}.tar.gz",
+				ChecksumURL:    "https://get.helm.sh/helm-v{{.Version}}-{{.OS}}-{{.Arch}}.tar.gz.sha256sum",
+				Archive:        ArchiveTarGz,
+				BinaryPath:     "{{.OS}}-{{.Arch}}/helm",
+			},
 		},
 		{
 			BinaryName:     "mockery",
 			GetVersionArgs: []string{"--version", "--quiet"},
+			Release: &Release{
+				DefaultVersion: "2.2.2",
+				URL:            "https://github.com/vektra/mockery/releases/download/v{{.Version}}/mockery_{{.Version}}_{{title .OS}}_{{.Machine}}.tar.gz",
+				ChecksumURL:    "https://github.com/vektra/mockery/releases/download/v{{.Version}}/checksums.txt",
+				Archive:        ArchiveTarGz,
+			},
 		},
 		{
-			BinaryName:     "kubectl",
-			GetVersionArgs: []string{"version", "--client", "--short"},
+			BinaryName:       "kubectl",
+			GetVersionArgs:   []string{"version", "--client", "-o", "json"},
+			VersionExtractor: mustJSONPathVersionExtractor("{.clientVersion.gitVersion}"),
+			Release: &Release{
+				DefaultVersion: "1.21.1",
+				URL:            "https://dl.k8s.io/release/v{{.Version}}/bin/{{.OS}}/{{.Arch}}/kubectl",
+				ChecksumURL:    "https://dl.k8s.io/release/v{{.Version}}/bin/{{.OS}}/{{.Arch}}/kubectl.sha256",
+			},
 		},
 		{
-			BinaryName:     "kustomize",
-			GetVersionArgs: []string{"version", "--short"},
+			BinaryName:       "kustomize",
+			GetVersionArgs:   []string{"version", "--short"},
+			VersionExtractor: mustRegexVersionExtractor(`(?:kustomize/)?(?P<version>v[0-9]+\.[0-9]+\.[0-9]+[0-9A-Za-z.+-]*)`),
+			Release: &Release{
+				DefaultVersion: "4.1.3",
+				URL:            "https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize%2Fv{{.Version}}/kustomize_v{{.Version}}_{{.OS}}_{{.Arch}}.tar.gz",
+				ChecksumURL:    "https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize%2Fv{{.Version}}/checksums.txt",
+				Archive:        ArchiveTarGz,
+			},
 		},
+		// controller-gen doesn't publish release binaries
 		{
-			BinaryName:     "controller-gen",
-			GetVersionArgs: []string{"--version"},
+			BinaryName:       "controller-gen",
+			GetVersionArgs:   []string{"--version"},
+			VersionExtractor: mustRegexVersionExtractor(`Version: (?P<version>\S+)`),
 		},
 	}
 )
@@ -64,6 +112,14 @@ type Dependency struct {
 	BinaryName string
 	// Arguments passed to the binary in order to get it version
 	GetVersionArgs []string
+	// VersionExtractor extracts the version from the binary output. If it's
+	// nil the first semantic version found in the output is returned.
+	VersionExtractor VersionExtractor
+	// Optional dependencies are not required to be installed
+	Optional bool
+	// Release describes where the dependency binaries can be downloaded. It
+	// is nil if the dependency cannot be installed by ackdev.
+	Release *Release
 }
 
 // BinPath returns the path of a binary if it exists
@@ -75,34 +131,59 @@ func (t *Dependency) BinPath() (string, error) {
 	return path, nil
 }
 
-// Version returns the version of the binary.
-func (t *Dependency) Version() (string, error) {
-	cmd := exec.Command(t.BinaryName, t.GetVersionArgs...)
-	b, err := cmd.CombinedOutput()
+// Version returns the version of the binary. The version command is killed
+// when ctx is done. It returns an error wrapping ErrorVersionNotFound if the
+// version cannot be found in the binary output.
+func (t *Dependency) Version(ctx context.Context) (Version, error) {
+	out, err := t.probe(ctx)
 	if err != nil {
-		return "", err
+		return Version{}, err
 	}
+	return t.extractVersion(out)
+}
 
-	version := getVersionFromString(string(b))
-	if len(version) == 0 {
-		return "", ErrorVersionNotFound
+// extractHelmVersion extracts helm client version. Helm 2 prints both the
+// client and server (tiller) versions, or an error if tiller cannot be
+// reached, while helm 3 only prints the client version.
+func extractHelmVersion(output []byte) (Version, error) {
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "Server:") || strings.HasPrefix(line, "Error:") {
+			continue
+		}
+		if version := getVersionFromString(strings.TrimPrefix(line, "Client:")); version != "" {
+			return NormalizeVersion(version)
+		}
 	}
-
-	return version, nil
+	return Version{}, ErrorVersionNotFound
 }
 
 // getVersionFromString parses a string expression and returns the first
-// observed semantic version.
-// TODO(a-hilaly) find a better regex expression - currently this one returns
-// v1 if it gets 'thisisnotav1ersion'
+// observed semantic version. Versions must not be part of a word, except
+// for the 'go' prefix used by go releases (e.g go1.16.4).
 func getVersionFromString(s string) string {
-	regexExpr := `v?([0-9]+)(\.[0-9]+)?(\.[0-9]+)?` +
-		`(-([0-9A-Za-z\-]+(\.[0-9A-Za-z\-]+)*))?` +
-		`(\+([0-9A-Za-z\-]+(\.[0-9A-Za-z\-]+)*))?`
-	re := regexp.MustCompile(regexExpr) // panics if it doesn't compile
+	matches := versionRegex.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[1]
+}
+
+// getVersionFromRegex returns the version matched by a regular expression.
+// If the expression contains a group named 'version', or any other group,
+// only the group submatch is returned.
+func getVersionFromRegex(re *regexp.Regexp, s string) string {
 	matches := re.FindStringSubmatch(s)
 	if len(matches) == 0 {
 		return ""
 	}
+	for i, name := range re.SubexpNames() {
+		if name == "version" {
+			return matches[i]
+		}
+	}
+	if len(matches) > 1 {
+		return matches[1]
+	}
 	return matches[0]
 }
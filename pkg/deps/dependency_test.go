@@ -14,6 +14,7 @@
 package deps
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -48,6 +49,16 @@ func Test_getVersionFromString(t *testing.T) {
 			args{"someoutput 2.0.0-rc3 someotheroutput"},
 			"2.0.0-rc3",
 		},
+		{
+			"version inside a word",
+			args{"thisisnotav1ersion"},
+			"",
+		},
+		{
+			"version with a go prefix",
+			args{"go version go1.16.4 linux/amd64"},
+			"1.16.4",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -56,3 +67,23 @@ func Test_getVersionFromString(t *testing.T) {
 		})
 	}
 }
+
+func Test_getVersionFromRegex(t *testing.T) {
+	tests := []struct {
+		name  string
+		regex string
+		input string
+		want  string
+	}{
+		{"named group", `version (?P<major>\d+)\.(?P<version>[0-9.]+)`, "version 1.2.3", "2.3"},
+		{"first group", `jq-([0-9.]+)`, "jq-1.6\n", "1.6"},
+		{"whole match", `[0-9]+\.[0-9]+`, "aws-cli/2.2.5 Python/3.8.8", "2.2"},
+		{"no match", `jq-([0-9.]+)`, "something else", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getVersionFromRegex(regexp.MustCompile(tt.regex), tt.input)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
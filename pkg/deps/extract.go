@@ -0,0 +1,176 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/printer"
+)
+
+// VersionExtractor extracts a dependency version from the output of its
+// version command.
+type VersionExtractor interface {
+	// ExtractVersion returns the version found in output. It returns an
+	// error wrapping ErrorVersionNotFound if output doesn't contain a
+	// semantic version.
+	ExtractVersion(output []byte) (Version, error)
+}
+
+// VersionExtractorFunc is a function implementing VersionExtractor.
+type VersionExtractorFunc func(output []byte) (Version, error)
+
+// ExtractVersion implements VersionExtractor.
+func (f VersionExtractorFunc) ExtractVersion(output []byte) (Version, error) {
+	return f(output)
+}
+
+// RegexVersionExtractor extracts versions using a regular expression. If
+// the expression contains a group named 'version', or any other group, only
+// the group submatch is kept.
+type RegexVersionExtractor struct {
+	re *regexp.Regexp
+}
+
+// NewRegexVersionExtractor returns a RegexVersionExtractor using the given
+// regular expression.
+func NewRegexVersionExtractor(expr string) (*RegexVersionExtractor, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexVersionExtractor{re: re}, nil
+}
+
+// mustRegexVersionExtractor is like NewRegexVersionExtractor but panics if
+// the expression doesn't compile. It is used for built-in dependencies.
+func mustRegexVersionExtractor(expr string) *RegexVersionExtractor {
+	return &RegexVersionExtractor{re: regexp.MustCompile(expr)}
+}
+
+// String returns the extractor regular expression
+func (e *RegexVersionExtractor) String() string {
+	return e.re.String()
+}
+
+// ExtractVersion implements VersionExtractor.
+func (e *RegexVersionExtractor) ExtractVersion(output []byte) (Version, error) {
+	version := getVersionFromRegex(e.re, string(output))
+	if version == "" {
+		return Version{}, ErrorVersionNotFound
+	}
+	return NormalizeVersion(version)
+}
+
+// JSONPathVersionExtractor extracts versions from JSON outputs, such as the
+// output of 'kubectl version -o json'.
+type JSONPathVersionExtractor struct {
+	template string
+	path     *printer.JSONPath
+}
+
+// NewJSONPathVersionExtractor returns a JSONPathVersionExtractor using the
+// given JSONPath template, e.g '{.clientVersion.gitVersion}'. Braces are
+// optional for templates made of a single path.
+func NewJSONPathVersionExtractor(template string) (*JSONPathVersionExtractor, error) {
+	if !strings.Contains(template, "{") {
+		template = "{" + template + "}"
+	}
+	path, err := printer.ParseJSONPath(template)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPathVersionExtractor{template: template, path: path}, nil
+}
+
+// mustJSONPathVersionExtractor is like NewJSONPathVersionExtractor but
+// panics if the template cannot be parsed. It is used for built-in
+// dependencies.
+func mustJSONPathVersionExtractor(template string) *JSONPathVersionExtractor {
+	e, err := NewJSONPathVersionExtractor(template)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// String returns the extractor JSONPath template
+func (e *JSONPathVersionExtractor) String() string {
+	return e.template
+}
+
+// ExtractVersion implements VersionExtractor.
+func (e *JSONPathVersionExtractor) ExtractVersion(output []byte) (Version, error) {
+	var data interface{}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return Version{}, fmt.Errorf("%w: invalid JSON output: %v", ErrorVersionNotFound, err)
+	}
+
+	var buf bytes.Buffer
+	if err := e.path.Execute(&buf, data); err != nil {
+		return Version{}, err
+	}
+	version := strings.TrimSpace(buf.String())
+	if version == "" {
+		return Version{}, fmt.Errorf("%w: %s not found in JSON output", ErrorVersionNotFound, e.template)
+	}
+	return NormalizeVersion(version)
+}
+
+// defaultVersionExtractor returns the first semantic version found in the
+// output.
+var defaultVersionExtractor = VersionExtractorFunc(func(output []byte) (Version, error) {
+	version := getVersionFromString(string(output))
+	if version == "" {
+		return Version{}, ErrorVersionNotFound
+	}
+	return NormalizeVersion(version)
+})
+
+// normalizeVersionRegex matches the versions accepted by NormalizeVersion
+var normalizeVersionRegex = regexp.MustCompile(
+	`^(?:v|go)?([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?` +
+		`(?:-([0-9A-Za-z.-]+)|([A-Za-z][0-9A-Za-z.-]*))?` +
+		`(?:\+([0-9A-Za-z.-]+))?$`,
+)
+
+// NormalizeVersion converts the versions printed by tools into semantic
+// versions. It accepts 'v' and 'go' prefixes, missing minor and patch
+// numbers, and pre-release suffixes without hyphen, e.g "go1.17rc1" is
+// normalized to 1.17.0-rc1.
+func NormalizeVersion(s string) (Version, error) {
+	matches := normalizeVersionRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return Version{}, fmt.Errorf("%w: %q is not a semantic version", ErrorVersionNotFound, s)
+	}
+
+	normalized := matches[1]
+	for _, n := range matches[2:4] {
+		if n == "" {
+			n = "0"
+		}
+		normalized += "." + n
+	}
+	if prerelease := matches[4] + matches[5]; prerelease != "" {
+		normalized += "-" + prerelease
+	}
+	if matches[6] != "" {
+		normalized += "+" + matches[6]
+	}
+	return ParseVersion(normalized)
+}
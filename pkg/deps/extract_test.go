@@ -0,0 +1,132 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kubectlVersionJSONOutput = `{
+  "clientVersion": {
+    "major": "1",
+    "minor": "21",
+    "gitVersion": "v1.21.1",
+    "gitCommit": "5e58841cce77d4bc13713ad2b91fa0d961e69192",
+    "gitTreeState": "clean",
+    "buildDate": "2021-05-12T14:18:45Z",
+    "goVersion": "go1.16.4",
+    "compiler": "gc",
+    "platform": "linux/amd64"
+  }
+}
+`
+
+func TestDevelopmentTools_versionExtractors(t *testing.T) {
+	tests := []struct {
+		tool   string
+		output string
+		want   string
+	}{
+		{"go", "go version go1.16.4 linux/amd64\n", "1.16.4"},
+		{"go", "go version go1.15 darwin/amd64\n", "1.15.0"},
+		{"go", "go version go1.17rc1 darwin/arm64\n", "1.17.0-rc1"},
+		{"kind", "kind version 0.11.1\n", "0.11.1"},
+		{"kind", "kind v0.11.1 go1.16.4 linux/amd64\n", "0.11.1"},
+		{"helm", "v3.5.4+g1b5edb6\n", "3.5.4+g1b5edb6"},
+		{"helm", "Client: v2.16.1+gbbdfe5e\nError: could not find tiller\n", "2.16.1+gbbdfe5e"},
+		{"helm", "Client: v2.16.1+gbbdfe5e\nServer: v2.14.3+g0e7f3b6\n", "2.16.1+gbbdfe5e"},
+		{"mockery", "v2.2.2\n", "2.2.2"},
+		{"mockery", "0.0.0-dev\n", "0.0.0-dev"},
+		{"kubectl", kubectlVersionJSONOutput, "1.21.1"},
+		{"kustomize", "{kustomize/v4.1.3  2021-05-20T20:52:40Z  }\n", "4.1.3"},
+		{"kustomize", "{kustomize/v3.8.7  2020-11-09T23:24:46Z  }\n", "3.8.7"},
+		{"kustomize", "v5.0.1\n", "5.0.1"},
+		{"controller-gen", "Version: v0.4.0\n", "0.4.0"},
+		{"controller-gen", "Version: v0.6.0-beta.0\n", "0.6.0-beta.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+tt.want, func(t *testing.T) {
+			var dep *Dependency
+			for i := range DevelopmentTools {
+				if DevelopmentTools[i].BinaryName == tt.tool {
+					dep = &DevelopmentTools[i]
+				}
+			}
+			require.NotNil(t, dep)
+
+			extractor := dep.VersionExtractor
+			if extractor == nil {
+				extractor = defaultVersionExtractor
+			}
+			v, err := extractor.ExtractVersion([]byte(tt.output))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, v.String())
+		})
+	}
+}
+
+func TestVersionExtractors_notFound(t *testing.T) {
+	jsonPath, err := NewJSONPathVersionExtractor(".clientVersion.gitVersion")
+	require.NoError(t, err)
+	regex, err := NewRegexVersionExtractor(`Version: (?P<version>\S+)`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		extractor VersionExtractor
+		output    string
+	}{
+		{"default extractor", defaultVersionExtractor, "thisisnotav1ersion"},
+		{"regex not matching", regex, "controller-gen: unknown flag"},
+		{"regex matching an invalid version", regex, "Version: (devel)"},
+		{"invalid JSON", jsonPath, "Client Version: v1.21.1"},
+		{"missing JSON field", jsonPath, `{"serverVersion": {"gitVersion": "v1.20.0"}}`},
+		{"helm error", VersionExtractorFunc(extractHelmVersion), "Error: unknown flag: --short\n"},
+	}
+	for _, tt := range tests {
+		_, err := tt.extractor.ExtractVersion([]byte(tt.output))
+		assert.True(t, errors.Is(err, ErrorVersionNotFound), "%s: unexpected error %v", tt.name, err)
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"1.2.3", "1.2.3", false},
+		{"v1.2", "1.2.0", false},
+		{"go1.16", "1.16.0", false},
+		{"go1.17beta1", "1.17.0-beta1", false},
+		{"v1.21.0-rc.0+abc", "1.21.0-rc.0+abc", false},
+		{"3", "3.0.0", false},
+		{"devel", "", true},
+		{"1.2.3.4", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		v, err := NormalizeVersion(tt.input)
+		if tt.wantErr {
+			assert.True(t, errors.Is(err, ErrorVersionNotFound), tt.input)
+			continue
+		}
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, v.String(), tt.input)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultDownloadTimeout is the maximum duration of a single download
+	defaultDownloadTimeout = 5 * time.Minute
+)
+
+// Fetcher downloads release artifacts.
+type Fetcher interface {
+	// Fetch returns the content located at url. Callers must close the
+	// returned reader.
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// HTTPFetcher is a Fetcher downloading artifacts over HTTP(S).
+type HTTPFetcher struct {
+	// Client is the HTTP client used to download artifacts. If it's nil a
+	// client with a default timeout is used.
+	Client *http.Client
+}
+
+// Fetch implements Fetcher.
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultDownloadTimeout}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("cannot download %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,440 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+var (
+	// ErrNoRelease is returned when a dependency doesn't publish binaries
+	// that can be installed by ackdev.
+	ErrNoRelease = errors.New("no downloadable release")
+	// ErrChecksumMismatch is returned when the checksum of a downloaded
+	// artifact doesn't match the expected one.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// ArchiveFormat is the format of a release artifact.
+type ArchiveFormat string
+
+const (
+	// ArchiveNone means that the artifact is the binary itself
+	ArchiveNone ArchiveFormat = ""
+	// ArchiveTarGz means that the artifact is a gzip compressed tarball
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+	// ArchiveZip means that the artifact is a zip archive
+	ArchiveZip ArchiveFormat = "zip"
+)
+
+// Release describes where the released binaries of a dependency can be
+// downloaded.
+//
+// URL, ChecksumURL and BinaryPath are go templates rendered with the
+// following fields: .Version (without the 'v' prefix), .OS and .Arch (Go
+// style, e.g linux and amd64) and .Machine (uname style architecture, e.g
+// x86_64). The 'title' function can be used to capitalise a field.
+type Release struct {
+	// DefaultVersion is the version installed when the dependency version
+	// isn't pinned to an exact version.
+	DefaultVersion string
+	// URL is the artifact URL template.
+	URL string
+	// ChecksumURL is the template of the URL of a file containing the
+	// artifact SHA256 checksum. The file can either contain a single hash
+	// or use the sha256sum format.
+	ChecksumURL string
+	// Checksums maps platforms (e.g linux/amd64) to the expected artifact
+	// SHA256 checksum. It takes precedence over ChecksumURL.
+	Checksums map[string]string
+	// Archive is the artifact format.
+	Archive ArchiveFormat
+	// BinaryPath is the template of the binary path inside the archive. By
+	// default the first file named after the binary is extracted.
+	BinaryPath string
+}
+
+// releaseParams are the fields available in Release templates
+type releaseParams struct {
+	Version string
+	OS      string
+	Arch    string
+	Machine string
+}
+
+// machineNames maps Go architectures to uname style machine names
+var machineNames = map[string]string{
+	"amd64": "x86_64",
+	"386":   "i386",
+	"arm64": "arm64",
+}
+
+// InstallerOption is a function that configures an Installer.
+type InstallerOption func(*Installer)
+
+// WithFetcher sets the fetcher used to download artifacts.
+func WithFetcher(fetcher Fetcher) InstallerOption {
+	return func(i *Installer) {
+		i.fetcher = fetcher
+	}
+}
+
+// WithPlatform sets the operating system and architecture of the installed
+// binaries. By default the current platform is used.
+func WithPlatform(goos, goarch string) InstallerOption {
+	return func(i *Installer) {
+		i.goos = goos
+		i.goarch = goarch
+	}
+}
+
+// Installer downloads dependencies releases into a bin directory.
+type Installer struct {
+	binDir  string
+	fetcher Fetcher
+	goos    string
+	goarch  string
+}
+
+// NewInstaller returns an Installer installing binaries into binDir.
+func NewInstaller(binDir string, opts ...InstallerOption) *Installer {
+	i := &Installer{
+		binDir:  binDir,
+		fetcher: &HTTPFetcher{},
+		goos:    runtime.GOOS,
+		goarch:  runtime.GOARCH,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// BinDir returns the directory where binaries are installed.
+func (i *Installer) BinDir() string {
+	return i.binDir
+}
+
+// ResolveVersion returns the version of a dependency that should be
+// installed. It is the version pinned by constraint if it's an exact
+// version, or the dependency release default version. constraint can be
+// nil.
+func ResolveVersion(dep Dependency, constraint *Constraint) (Version, error) {
+	if constraint != nil {
+		if v, ok := constraint.Exact(); ok {
+			return v, nil
+		}
+	}
+	if dep.Release == nil {
+		return Version{}, fmt.Errorf("%w for %s", ErrNoRelease, dep.BinaryName)
+	}
+
+	v, err := ParseVersion(dep.Release.DefaultVersion)
+	if err != nil {
+		return Version{}, err
+	}
+	if constraint != nil && constraint.Check(v) != StatusOK {
+		return Version{}, fmt.Errorf(
+			"cannot choose %s version: default version %s doesn't satisfy %q, pin an exact version",
+			dep.BinaryName, v, constraint,
+		)
+	}
+	return v, nil
+}
+
+// Install downloads the given version of a dependency, verifies its
+// checksum and installs its binary into the installer bin directory. It
+// returns the path of the installed binary.
+func (i *Installer) Install(ctx context.Context, dep Dependency, version Version) (string, error) {
+	release := dep.Release
+	if release == nil {
+		return "", fmt.Errorf("%w for %s", ErrNoRelease, dep.BinaryName)
+	}
+
+	params := releaseParams{
+		Version: version.String(),
+		OS:      i.goos,
+		Arch:    i.goarch,
+		Machine: machineNames[i.goarch],
+	}
+	if params.Machine == "" {
+		params.Machine = i.goarch
+	}
+	artifactURL, err := renderReleaseTemplate(release.URL, params)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(i.binDir, 0755); err != nil {
+		return "", err
+	}
+
+	artifact, checksum, err := i.download(ctx, artifactURL)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(artifact)
+
+	expected, err := i.expectedChecksum(ctx, release, params, artifactURL)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(expected, checksum) {
+		return "", fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, artifactURL, expected, checksum)
+	}
+
+	binaryName := dep.BinaryName
+	if i.goos == "windows" {
+		binaryName += ".exe"
+	}
+	binaryPath := ""
+	if release.BinaryPath != "" {
+		binaryPath, err = renderReleaseTemplate(release.BinaryPath, params)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	binary := artifact
+	switch release.Archive {
+	case ArchiveNone:
+	case ArchiveTarGz:
+		binary, err = i.extractTarGz(artifact, binaryName, binaryPath)
+	case ArchiveZip:
+		binary, err = i.extractZip(artifact, binaryName, binaryPath)
+	default:
+		err = fmt.Errorf("unsupported archive format %q", release.Archive)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(binary)
+
+	if err := os.Chmod(binary, 0755); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(i.binDir, binaryName)
+	if err := os.Rename(binary, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// download fetches url into a temporary file of the bin directory and
+// returns the file path along with its SHA256 checksum.
+func (i *Installer) download(ctx context.Context, url string) (string, string, error) {
+	body, err := i.fetcher.Fetch(ctx, url)
+	if err != nil {
+		return "", "", err
+	}
+	defer body.Close()
+
+	f, err := ioutil.TempFile(i.binDir, ".download-")
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), body); err != nil {
+		os.Remove(f.Name())
+		return "", "", fmt.Errorf("cannot download %s: %v", url, err)
+	}
+	return f.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// expectedChecksum returns the expected SHA256 checksum of an artifact.
+func (i *Installer) expectedChecksum(
+	ctx context.Context,
+	release *Release,
+	params releaseParams,
+	artifactURL string,
+) (string, error) {
+	if checksum, ok := release.Checksums[i.goos+"/"+i.goarch]; ok {
+		return checksum, nil
+	}
+	if release.ChecksumURL == "" {
+		return "", fmt.Errorf("no checksum available for %s", artifactURL)
+	}
+
+	checksumURL, err := renderReleaseTemplate(release.ChecksumURL, params)
+	if err != nil {
+		return "", err
+	}
+	body, err := i.fetcher.Fetch(ctx, checksumURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	checksum, ok := findChecksum(content, artifactFileName(artifactURL))
+	if !ok {
+		return "", fmt.Errorf("no checksum found for %s in %s", artifactURL, checksumURL)
+	}
+	return checksum, nil
+}
+
+// findChecksum returns the checksum of fileName in a checksums file. The
+// file can either contain a single hash or lines following the sha256sum
+// format ("<hash>  <file name>").
+func findChecksum(content []byte, fileName string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lines [][]string
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+
+	if len(lines) == 1 && len(lines[0]) == 1 {
+		return lines[0][0], true
+	}
+	for _, fields := range lines {
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum prefixes file names with '*' in binary mode
+		if path.Base(strings.TrimPrefix(fields[1], "*")) == fileName {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
+
+// artifactFileName returns the unescaped file name of an artifact URL.
+func artifactFileName(artifactURL string) string {
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return path.Base(artifactURL)
+	}
+	return path.Base(u.Path)
+}
+
+// extractTarGz extracts a binary from a gzip compressed tarball and returns
+// the path of the extracted file.
+func (i *Installer) extractTarGz(archive, binaryName, binaryPath string) (string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("cannot read archive: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot read archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg || !matchArchiveEntry(header.Name, binaryName, binaryPath) {
+			continue
+		}
+		return i.writeTempFile(tr)
+	}
+	return "", fmt.Errorf("%s not found in archive", binaryName)
+}
+
+// extractZip extracts a binary from a zip archive and returns the path of
+// the extracted file.
+func (i *Installer) extractZip(archive, binaryName, binaryPath string) (string, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return "", fmt.Errorf("cannot read archive: %v", err)
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() || !matchArchiveEntry(file.Name, binaryName, binaryPath) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		return i.writeTempFile(rc)
+	}
+	return "", fmt.Errorf("%s not found in archive", binaryName)
+}
+
+// matchArchiveEntry returns true if an archive entry is the binary to
+// extract.
+func matchArchiveEntry(name, binaryName, binaryPath string) bool {
+	name = strings.TrimPrefix(path.Clean(name), "./")
+	if binaryPath != "" {
+		return name == strings.TrimPrefix(path.Clean(binaryPath), "./")
+	}
+	return path.Base(name) == binaryName
+}
+
+// writeTempFile copies r into a temporary file of the bin directory.
+func (i *Installer) writeTempFile(r io.Reader) (string, error) {
+	f, err := ioutil.TempFile(i.binDir, ".extract-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// renderReleaseTemplate renders a Release template.
+func renderReleaseTemplate(text string, params releaseParams) (string, error) {
+	tmpl, err := template.New("release").Funcs(template.FuncMap{
+		"title": strings.Title,
+	}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
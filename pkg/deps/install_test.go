@@ -0,0 +1,288 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func newZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInstaller_Install(t *testing.T) {
+	tarball := newTarGz(t, map[string]string{
+		"README.md":         "readme",
+		"linux-amd64/tool":  "tarball binary",
+		"darwin-amd64/tool": "wrong binary",
+	})
+	zipArchive := newZip(t, map[string]string{
+		"bin/tool": "zip binary",
+		"LICENSE":  "license",
+	})
+	plain := []byte("plain binary")
+
+	files := map[string][]byte{
+		"/v1.2.3/tool-linux-amd64.tar.gz":     tarball,
+		"/v1.2.3/tool_1.2.3_Linux_x86_64.zip": zipArchive,
+		"/v1.2.3/tool-linux-amd64":            plain,
+		"/v1.2.3/tool-linux-amd64.sha256":     []byte(sha256Hex(plain) + "\n"),
+		"/v1.2.3/checksums.txt": []byte(fmt.Sprintf(
+			"%s  tool-linux-amd64.tar.gz\n%s  tool_1.2.3_Linux_x86_64.zip\n",
+			sha256Hex(tarball), sha256Hex(zipArchive),
+		)),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		release     *Release
+		wantContent string
+		wantErr     error
+	}{
+		{
+			name: "tar.gz archive with binary path",
+			release: &Release{
+				URL:         server.URL + "/v{{.Version}}/tool-{{.OS}}-{{.Arch}}.tar.gz",
+				ChecksumURL: server.URL + "/v{{.Version}}/checksums.txt",
+				Archive:     ArchiveTarGz,
+				BinaryPath:  "{{.OS}}-{{.Arch}}/tool",
+			},
+			wantContent: "tarball binary",
+		},
+		{
+			name: "zip archive",
+			release: &Release{
+				URL:         server.URL + "/v{{.Version}}/tool_{{.Version}}_{{title .OS}}_{{.Machine}}.zip",
+				ChecksumURL: server.URL + "/v{{.Version}}/checksums.txt",
+				Archive:     ArchiveZip,
+			},
+			wantContent: "zip binary",
+		},
+		{
+			name: "plain binary with single hash checksum file",
+			release: &Release{
+				URL:         server.URL + "/v{{.Version}}/tool-{{.OS}}-{{.Arch}}",
+				ChecksumURL: server.URL + "/v{{.Version}}/tool-{{.OS}}-{{.Arch}}.sha256",
+			},
+			wantContent: "plain binary",
+		},
+		{
+			name: "pinned checksum",
+			release: &Release{
+				URL:       server.URL + "/v{{.Version}}/tool-{{.OS}}-{{.Arch}}",
+				Checksums: map[string]string{"linux/amd64": sha256Hex(plain)},
+			},
+			wantContent: "plain binary",
+		},
+		{
+			name: "checksum mismatch",
+			release: &Release{
+				URL:       server.URL + "/v{{.Version}}/tool-{{.OS}}-{{.Arch}}",
+				Checksums: map[string]string{"linux/amd64": sha256Hex([]byte("something else"))},
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binDir, err := ioutil.TempDir("", "ackdev-install")
+			require.NoError(t, err)
+			defer os.RemoveAll(binDir)
+
+			installer := NewInstaller(binDir, WithPlatform("linux", "amd64"))
+			path, err := installer.Install(
+				context.Background(),
+				Dependency{BinaryName: "tool", Release: tt.release},
+				Version{Major: 1, Minor: 2, Patch: 3},
+			)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+				_, err := os.Stat(filepath.Join(binDir, "tool"))
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(binDir, "tool"), path)
+
+			content, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContent, string(content))
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+			// temporary files are cleaned up
+			entries, err := ioutil.ReadDir(binDir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestInstaller_Install_errors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	binDir, err := ioutil.TempDir("", "ackdev-install")
+	require.NoError(t, err)
+	defer os.RemoveAll(binDir)
+	installer := NewInstaller(binDir)
+
+	_, err = installer.Install(context.Background(), Dependency{BinaryName: "go"}, Version{Major: 1})
+	assert.True(t, errors.Is(err, ErrNoRelease))
+
+	_, err = installer.Install(context.Background(), Dependency{
+		BinaryName: "tool",
+		Release:    &Release{URL: server.URL + "/tool"},
+	}, Version{Major: 1})
+	assert.EqualError(t, err, fmt.Sprintf("cannot download %s/tool: 404 Not Found", server.URL))
+}
+
+func TestResolveVersion(t *testing.T) {
+	dep := Dependency{BinaryName: "kind", Release: &Release{DefaultVersion: "0.11.1"}}
+	mustConstraint := func(s string) *Constraint {
+		c, err := ParseConstraint(s)
+		require.NoError(t, err)
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		dep        Dependency
+		constraint *Constraint
+		want       string
+		wantErr    string
+	}{
+		{"no constraint", dep, nil, "0.11.1", ""},
+		{"exact constraint", dep, mustConstraint("v0.10.0"), "0.10.0", ""},
+		{"range satisfied by default version", dep, mustConstraint(">=0.11"), "0.11.1", ""},
+		{"range not satisfied by default version", dep, mustConstraint("0.9.x"), "",
+			`cannot choose kind version: default version 0.11.1 doesn't satisfy "0.9.x", pin an exact version`},
+		{"no release", Dependency{BinaryName: "go"}, nil, "", "no downloadable release for go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := ResolveVersion(tt.dep, tt.constraint)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, v.String())
+		})
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	content := []byte("aaa  kind-linux-amd64\nbbb *dist/kind-darwin-amd64\n\n")
+
+	checksum, ok := findChecksum(content, "kind-darwin-amd64")
+	assert.True(t, ok)
+	assert.Equal(t, "bbb", checksum)
+
+	_, ok = findChecksum(content, "kind-windows-amd64")
+	assert.False(t, ok)
+
+	checksum, ok = findChecksum([]byte("ccc\n"), "kubectl")
+	assert.True(t, ok)
+	assert.Equal(t, "ccc", checksum)
+}
+
+func TestDevelopmentTools_releases(t *testing.T) {
+	want := map[string]string{
+		"kind":      "https://github.com/kubernetes-sigs/kind/releases/download/v0.11.1/kind-linux-amd64",
+		"helm":      "https://get.helm.sh/helm-v3.6.0-linux-amd64.tar.gz",
+		"mockery":   "https://github.com/vektra/mockery/releases/download/v2.2.2/mockery_2.2.2_Linux_x86_64.tar.gz",
+		"kubectl":   "https://dl.k8s.io/release/v1.21.1/bin/linux/amd64/kubectl",
+		"kustomize": "https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize%2Fv4.1.3/kustomize_v4.1.3_linux_amd64.tar.gz",
+	}
+	for _, dep := range DevelopmentTools {
+		if dep.Release == nil {
+			assert.NotContains(t, want, dep.BinaryName)
+			continue
+		}
+		v, err := ResolveVersion(dep, nil)
+		require.NoError(t, err, dep.BinaryName)
+
+		url, err := renderReleaseTemplate(dep.Release.URL, releaseParams{
+			Version: v.String(),
+			OS:      "linux",
+			Arch:    "amd64",
+			Machine: "x86_64",
+		})
+		require.NoError(t, err, dep.BinaryName)
+		assert.Equal(t, want[dep.BinaryName], url)
+		_, err = renderReleaseTemplate(dep.Release.ChecksumURL, releaseParams{})
+		assert.NoError(t, err, dep.BinaryName)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"fmt"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+)
+
+// defaultVersionArgs are the version arguments of configured dependencies
+// that don't declare any. Running a binary without arguments can start a
+// server or an interactive session.
+var defaultVersionArgs = []string{"--version"}
+
+// Merge returns the built-in dependencies merged with the dependencies
+// declared in the configuration. A configured dependency named after a
+// built-in one overrides its specified settings, overriding its version
+// arguments without a version extractor resets the extractor to the default
+// one. Other configured dependencies are appended to the list and default to
+// the --version argument.
+func Merge(builtins []Dependency, configs []config.DependencyConfig) ([]Dependency, error) {
+	merged := make([]Dependency, len(builtins), len(builtins)+len(configs))
+	copy(merged, builtins)
+
+	index := make(map[string]int, len(merged))
+	for i, dep := range merged {
+		index[dep.BinaryName] = i
+	}
+
+	declared := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("invalid dependency: missing name")
+		}
+		if declared[cfg.Name] {
+			return nil, fmt.Errorf("invalid dependency %s: declared more than once", cfg.Name)
+		}
+		declared[cfg.Name] = true
+
+		var extractor VersionExtractor
+		switch {
+		case cfg.VersionRegex != "" && cfg.VersionJSONPath != "":
+			return nil, fmt.Errorf("invalid dependency %s: versionRegex and versionJSONPath are mutually exclusive", cfg.Name)
+		case cfg.VersionRegex != "":
+			e, err := NewRegexVersionExtractor(cfg.VersionRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dependency %s: invalid version regex: %v", cfg.Name, err)
+			}
+			extractor = e
+		case cfg.VersionJSONPath != "":
+			e, err := NewJSONPathVersionExtractor(cfg.VersionJSONPath)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dependency %s: %v", cfg.Name, err)
+			}
+			extractor = e
+		}
+
+		i, ok := index[cfg.Name]
+		if !ok {
+			merged = append(merged, Dependency{BinaryName: cfg.Name, GetVersionArgs: append([]string(nil), defaultVersionArgs...)})
+			i = len(merged) - 1
+			index[cfg.Name] = i
+		}
+		dep := &merged[i]
+		if cfg.VersionArgs != nil {
+			dep.GetVersionArgs = cfg.VersionArgs
+			// the built-in extractor expects the output of the built-in
+			// version arguments
+			dep.VersionExtractor = nil
+		}
+		if extractor != nil {
+			dep.VersionExtractor = extractor
+		}
+		if cfg.Optional != nil {
+			dep.Optional = *cfg.Optional
+		}
+	}
+	return merged, nil
+}
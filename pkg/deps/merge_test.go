@@ -0,0 +1,130 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+)
+
+func TestMerge(t *testing.T) {
+	builtins := []Dependency{
+		{BinaryName: "go", GetVersionArgs: []string{"version"}},
+		{BinaryName: "kubectl", GetVersionArgs: []string{"version", "--client"}},
+	}
+
+	merged, err := Merge(builtins, []config.DependencyConfig{
+		{Name: "jq", VersionArgs: []string{"--version"}, Optional: boolPtr(true)},
+		{Name: "kubectl", Optional: boolPtr(true), VersionJSONPath: ".clientVersion.gitVersion"},
+		{Name: "go", VersionArgs: []string{"env", "GOVERSION"}, VersionRegex: `go(?P<version>\S+)`},
+		{Name: "yq"},
+	})
+	require.NoError(t, err)
+	require.Len(t, merged, 4)
+
+	assert.Equal(t, "go", merged[0].BinaryName)
+	assert.Equal(t, []string{"env", "GOVERSION"}, merged[0].GetVersionArgs)
+	require.IsType(t, &RegexVersionExtractor{}, merged[0].VersionExtractor)
+	assert.Equal(t, `go(?P<version>\S+)`, merged[0].VersionExtractor.(*RegexVersionExtractor).String())
+	assert.False(t, merged[0].Optional)
+
+	assert.Equal(t, "kubectl", merged[1].BinaryName)
+	assert.Equal(t, []string{"version", "--client"}, merged[1].GetVersionArgs)
+	assert.True(t, merged[1].Optional)
+	require.IsType(t, &JSONPathVersionExtractor{}, merged[1].VersionExtractor)
+	assert.Equal(t, "{.clientVersion.gitVersion}", merged[1].VersionExtractor.(*JSONPathVersionExtractor).String())
+
+	assert.Equal(t, Dependency{BinaryName: "jq", GetVersionArgs: []string{"--version"}, Optional: true}, merged[2])
+	// configured dependencies without version arguments aren't run bare
+	assert.Equal(t, Dependency{BinaryName: "yq", GetVersionArgs: []string{"--version"}}, merged[3])
+
+	// built-ins are left untouched
+	assert.False(t, builtins[1].Optional)
+	assert.Equal(t, []string{"version"}, builtins[0].GetVersionArgs)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestMerge_overrides(t *testing.T) {
+	builtins := []Dependency{
+		{
+			BinaryName:       "helm",
+			GetVersionArgs:   []string{"version", "--short"},
+			VersionExtractor: mustRegexVersionExtractor(`v(?P<version>\S+)\+`),
+		},
+		{BinaryName: "kind", GetVersionArgs: []string{"--version"}, Optional: true},
+		{BinaryName: "kustomize", GetVersionArgs: []string{"version"}, Optional: true},
+	}
+
+	merged, err := Merge(builtins, []config.DependencyConfig{
+		// the built-in extractor doesn't match the new command output
+		{Name: "helm", VersionArgs: []string{"version", "--template", "{{.Version}}"}},
+		{Name: "kind", Optional: boolPtr(false)},
+		{Name: "kustomize", VersionArgs: []string{"version", "--short"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, merged, 3)
+
+	assert.Equal(t, []string{"version", "--template", "{{.Version}}"}, merged[0].GetVersionArgs)
+	assert.Nil(t, merged[0].VersionExtractor)
+	assert.NotNil(t, builtins[0].VersionExtractor)
+
+	// optional: false makes an optional built-in dependency required
+	assert.False(t, merged[1].Optional)
+	// unspecified settings are kept
+	assert.True(t, merged[2].Optional)
+}
+
+func TestMerge_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []config.DependencyConfig
+		wantErr string
+	}{
+		{"missing name", []config.DependencyConfig{{VersionArgs: []string{"--version"}}}, "invalid dependency: missing name"},
+		{"duplicate", []config.DependencyConfig{{Name: "jq"}, {Name: "jq"}}, "invalid dependency jq: declared more than once"},
+		{"invalid regex", []config.DependencyConfig{{Name: "jq", VersionRegex: "(["}},
+			"invalid dependency jq: invalid version regex: error parsing regexp: missing closing ]: `[`"},
+		{"regex and jsonpath", []config.DependencyConfig{{Name: "jq", VersionRegex: ".*", VersionJSONPath: ".version"}},
+			"invalid dependency jq: versionRegex and versionJSONPath are mutually exclusive"},
+	}
+	for _, tt := range tests {
+		_, err := Merge(DevelopmentTools, tt.configs)
+		assert.EqualError(t, err, tt.wantErr, tt.name)
+	}
+}
+
+func TestCheckResult_Compliant(t *testing.T) {
+	tests := []struct {
+		status   Status
+		optional bool
+		want     bool
+	}{
+		{StatusOK, false, true},
+		{StatusMissing, false, false},
+		{StatusMissing, true, true},
+		{StatusTooOld, true, false},
+		{StatusUnknown, false, false},
+	}
+	for _, tt := range tests {
+		r := &CheckResult{Dependency: Dependency{Optional: tt.optional}, Status: tt.status}
+		assert.Equal(t, tt.want, r.Compliant(), "%s optional=%t", tt.status, tt.optional)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/asyncexec"
+)
+
+const (
+	// DefaultProbeTimeout is the default maximum duration of a dependency
+	// version command.
+	DefaultProbeTimeout = 10 * time.Second
+	// probeOutputBuffer is the number of output lines buffered while
+	// probing a dependency
+	probeOutputBuffer = 64
+	// probeGracePeriod is the duration a timed out version command has to
+	// terminate before being killed
+	probeGracePeriod = time.Second
+)
+
+var (
+	// ErrProbeTimeout is returned when a dependency version command doesn't
+	// complete in time.
+	ErrProbeTimeout = errors.New("version command timed out")
+)
+
+// ProbeError is returned when a dependency version command fails.
+type ProbeError struct {
+	// Command is the executed command line
+	Command string
+	// ExitCode is the command exit code, or -1 if it didn't exit normally
+	ExitCode int
+	// Stderr is the command standard error output
+	Stderr string
+}
+
+// Error implements error.
+func (e *ProbeError) Error() string {
+	msg := fmt.Sprintf("%s exited with code %d", e.Command, e.ExitCode)
+	if lines := strings.Split(strings.TrimSpace(e.Stderr), "\n"); lines[len(lines)-1] != "" {
+		msg += ": " + lines[len(lines)-1]
+	}
+	return msg
+}
+
+// probeOutput is the output of a dependency version command
+type probeOutput struct {
+	stdout []byte
+	stderr []byte
+}
+
+// probe runs the dependency version command and returns its outputs. The
+// command, and any process it spawned, is stopped when ctx is done. If the
+// command fails after ctx deadline is exceeded ErrProbeTimeout is returned, if
+// it fails after ctx is cancelled its error is returned.
+func (t *Dependency) probe(ctx context.Context) (*probeOutput, error) {
+	cmd := exec.Command(t.BinaryName, t.GetVersionArgs...)
+	acmd := asyncexec.NewWithContext(
+		ctx, cmd, probeOutputBuffer,
+		asyncexec.WithGracePeriod(probeGracePeriod),
+	)
+	if err := acmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	collect := func(buf *bytes.Buffer, stream <-chan []byte) {
+		defer wg.Done()
+		for line := range stream {
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+	}
+	go collect(&stdout, acmd.StdoutStream())
+	go collect(&stderr, acmd.StderrStream())
+	wg.Wait()
+
+	out := &probeOutput{stdout: stdout.Bytes(), stderr: stderr.Bytes()}
+	err := acmd.Wait()
+	if err == nil {
+		return out, nil
+	}
+	// the command failed, it was stopped if ctx is done
+	switch ctx.Err() {
+	case nil:
+	case context.DeadlineExceeded:
+		return out, ErrProbeTimeout
+	default:
+		// the probe was cancelled, e.g by Ctrl+C
+		return out, ctx.Err()
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return out, err
+	}
+	return out, &ProbeError{
+		Command:  strings.Join(append([]string{t.BinaryName}, t.GetVersionArgs...), " "),
+		ExitCode: exitErr.ExitCode(),
+		Stderr:   string(out.stderr),
+	}
+}
+
+// extractVersion extracts the dependency version from a version command
+// output. The standard output is used first, then the error output.
+func (t *Dependency) extractVersion(out *probeOutput) (Version, error) {
+	extractor := t.VersionExtractor
+	if extractor == nil {
+		extractor = defaultVersionExtractor
+	}
+
+	v, err := extractor.ExtractVersion(out.stdout)
+	if errors.Is(err, ErrorVersionNotFound) && len(out.stderr) > 0 {
+		// some tools print their version on stderr
+		if v, stderrErr := extractor.ExtractVersion(out.stderr); stderrErr == nil {
+			return v, nil
+		}
+	}
+	return v, err
+}
+
+// CheckAll checks dependencies concurrently. Each version command is
+// bounded by timeout. Results are returned in the same order as
+// dependencies.
+func CheckAll(
+	ctx context.Context,
+	dependencies []Dependency,
+	constraints map[string]*Constraint,
+	timeout time.Duration,
+) []*CheckResult {
+	results := make([]*CheckResult, len(dependencies))
+
+	var wg sync.WaitGroup
+	for i := range dependencies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			dep := dependencies[i]
+			results[i] = Check(probeCtx, dep, constraints[dep.BinaryName])
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
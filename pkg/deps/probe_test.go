@@ -0,0 +1,175 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build !windows
+
+package deps
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeTool writes an executable shell script and returns its path.
+func newFakeTool(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755)
+	require.NoError(t, err)
+	return path
+}
+
+func TestCheckAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-deps")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	kind := newFakeTool(t, dir, "kind", `echo "kind version 0.11.1"`)
+	oldKind := newFakeTool(t, dir, "old-kind", `echo "kind version 0.9.0"`)
+	javaLike := newFakeTool(t, dir, "java", `echo 'openjdk version "11.0.11" 2021-04-20' >&2`)
+	hanging := newFakeTool(t, dir, "kubectl", "sleep 10\necho v1.0.0")
+	failing := newFakeTool(t, dir, "mockery", `echo "starting" >&2; echo "unknown flag: --quiet" >&2; exit 3`)
+	noVersion := newFakeTool(t, dir, "helm", `echo "no version here"`)
+
+	dependencies := []Dependency{
+		{BinaryName: kind, GetVersionArgs: []string{"--version"}},
+		{BinaryName: oldKind},
+		{BinaryName: javaLike},
+		{BinaryName: hanging},
+		{BinaryName: failing, GetVersionArgs: []string{"--version", "--quiet"}},
+		{BinaryName: noVersion},
+		{BinaryName: noVersion},
+		{BinaryName: filepath.Join(dir, "missing")},
+	}
+	mustConstraint := func(s string) *Constraint {
+		c, err := ParseConstraint(s)
+		require.NoError(t, err)
+		return c
+	}
+	constraints := map[string]*Constraint{
+		kind:    mustConstraint("0.11.x"),
+		oldKind: mustConstraint(">=0.11"),
+		// the second helm dependency has the same binary, both are pinned
+		noVersion: mustConstraint("3.x"),
+	}
+
+	start := time.Now()
+	results := CheckAll(context.Background(), dependencies, constraints, 500*time.Millisecond)
+	assert.True(t, time.Since(start) < 5*time.Second, "dependencies should be probed concurrently with a timeout")
+	require.Len(t, results, len(dependencies))
+
+	tests := []struct {
+		status  Status
+		version string
+		err     error
+	}{
+		{StatusOK, "0.11.1", nil},
+		{StatusTooOld, "0.9.0", nil},
+		{StatusOK, "11.0.11", nil},
+		{StatusTimeout, "", ErrProbeTimeout},
+		{StatusFailed, "", nil},
+		{StatusUnknown, "", ErrorVersionNotFound},
+		{StatusUnknown, "", ErrorVersionNotFound},
+		{StatusMissing, "", nil},
+	}
+	for i, tt := range tests {
+		res := results[i]
+		assert.Equal(t, dependencies[i].BinaryName, res.Dependency.BinaryName)
+		assert.Equal(t, tt.status, res.Status, res.Dependency.BinaryName)
+		assert.Equal(t, tt.version, res.Version, res.Dependency.BinaryName)
+		if tt.err != nil {
+			assert.True(t, errors.Is(res.Err, tt.err), "%s: unexpected error %v", res.Dependency.BinaryName, res.Err)
+		}
+	}
+
+	var probeErr *ProbeError
+	require.True(t, errors.As(results[4].Err, &probeErr))
+	assert.Equal(t, 3, probeErr.ExitCode)
+	assert.Equal(t, "starting\nunknown flag: --quiet\n", results[4].Stderr)
+	assert.Equal(t, failing+" --version --quiet exited with code 3: unknown flag: --quiet", probeErr.Error())
+}
+
+func TestCheckAll_cancelled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-deps")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dependencies := []Dependency{
+		{BinaryName: newFakeTool(t, dir, "hanging", "sleep 10\necho v1.0.0")},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	results := CheckAll(ctx, dependencies, nil, 5*time.Second)
+	assert.True(t, time.Since(start) < 4*time.Second)
+	require.Len(t, results, 1)
+	// cancelled probes are not reported as timed out
+	assert.Equal(t, StatusFailed, results[0].Status)
+	assert.True(t, errors.Is(results[0].Err, context.Canceled), "unexpected error %v", results[0].Err)
+}
+
+// expiringContext is a context which is never done but whose deadline is
+// reported as exceeded once the command started, as if the deadline passed
+// right after the command exited.
+type expiringContext struct {
+	context.Context
+	calls int32
+}
+
+func (c *expiringContext) Err() error {
+	// the first call checks the context before starting the command
+	if atomic.AddInt32(&c.calls, 1) == 1 {
+		return nil
+	}
+	return context.DeadlineExceeded
+}
+
+func TestDependency_probe_deadlineAfterExit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-deps")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ok := Dependency{BinaryName: newFakeTool(t, dir, "kind", `echo "kind version 0.11.1"`)}
+	out, err := ok.probe(&expiringContext{Context: context.Background()})
+	require.NoError(t, err)
+	assert.Equal(t, "kind version 0.11.1\n", string(out.stdout))
+
+	failing := Dependency{BinaryName: newFakeTool(t, dir, "mockery", "exit 3")}
+	_, err = failing.probe(&expiringContext{Context: context.Background()})
+	assert.Equal(t, ErrProbeTimeout, err)
+}
+
+func TestDependency_Version(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-deps")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dep := Dependency{BinaryName: newFakeTool(t, dir, "controller-gen", `echo "Version: v0.4.0"`)}
+	v, err := dep.Version(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.0", v.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = dep.Version(ctx)
+	assert.Error(t, err)
+}
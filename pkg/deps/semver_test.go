@@ -0,0 +1,83 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package deps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr bool
+	}{
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}, false},
+		{"v0.4.0", Version{Minor: 4}, false},
+		{"1.16", Version{Major: 1, Minor: 16}, false},
+		{"3", Version{Major: 3}, false},
+		{"v3.2.4+g0ad800e", Version{Major: 3, Minor: 2, Patch: 4, Build: "g0ad800e"}, false},
+		{"1.0.0-rc.1+build.5", Version{Major: 1, Prerelease: "rc.1", Build: "build.5"}, false},
+		{"", Version{}, true},
+		{"1.2.3.4", Version{}, true},
+		{"1.a.3", Version{}, true},
+		{"1.2.3-", Version{}, true},
+		{"1.2.3+", Version{}, true},
+		{"1..3", Version{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.input)
+		if tt.wantErr {
+			assert.Error(t, err, tt.input)
+			continue
+		}
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+}
+
+func TestVersion_String(t *testing.T) {
+	v, err := ParseVersion("v1.2.3-rc.1+abc")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3-rc.1+abc", v.String())
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "v1.2.3+build", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.3.0", "1.2.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+	}
+	for _, tt := range tests {
+		a, err := ParseVersion(tt.a)
+		require.NoError(t, err)
+		b, err := ParseVersion(tt.b)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, a.Compare(b), "%s <=> %s", tt.a, tt.b)
+		assert.Equal(t, -tt.want, b.Compare(a), "%s <=> %s", tt.b, tt.a)
+	}
+}
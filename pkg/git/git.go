@@ -18,12 +18,13 @@ import (
 
 	"golang.org/x/crypto/ssh"
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 	gitssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
 )
 
-var _ OpenCloner = &Git{}
+var _ Client = &Git{}
 
 const (
 	defaultUser = "git"
@@ -53,6 +54,38 @@ type OpenCloner interface {
 	Cloner
 }
 
+// Fetcher is the interface that wraps the Fetch method.
+//
+// Fetch fetches the references and objects of a repository remote.
+type Fetcher interface {
+	Fetch(
+		ctx context.Context,
+		repo *git.Repository,
+		remote string,
+	) error
+}
+
+// Pusher is the interface that wraps the Push method.
+//
+// Push updates the remote references of a repository using the given
+// refspecs.
+type Pusher interface {
+	Push(
+		ctx context.Context,
+		repo *git.Repository,
+		remote string,
+		refSpecs ...string,
+	) error
+}
+
+// Client is the interface that groups the Open, Clone, Fetch and Push
+// methods.
+type Client interface {
+	OpenCloner
+	Fetcher
+	Pusher
+}
+
 // New instanciate a new Git struct. It take a list of Option objects
 // to configure the remote and/or the authentication method.
 func New(options ...Option) *Git {
@@ -66,30 +99,80 @@ func New(options ...Option) *Git {
 // Git represents the components reponsible for cloning and
 // opening git repositories. It is supposed to hide the authentication
 // mechanisms used to clone repositories.
-// Git implements OpenCloner interface.
+// Git implements Client interface.
 type Git struct {
 	signer         ssh.Signer
+	signerFunc     func() (ssh.Signer, error)
+	sshAgent       bool
+	knownHosts     []string
 	remote         string
 	githubToken    string
 	githubUsername string
 }
 
-// Clone clones a remote git repository into a destination path. Clone will
-// prioritise SSH signer if it's set.
-func (g *Git) Clone(ctx context.Context, url, dest string) error {
-	var auth transport.AuthMethod
-	if g.signer != nil {
-		auth = &gitssh.PublicKeys{
-			User:   defaultUser,
-			Signer: g.signer,
+// noopCloser is returned by authMethod when there is no connection to close.
+var noopCloser = func() {}
+
+// authMethod returns the transport.AuthMethod used to communicate with
+// remote repositories. It prioritises the ssh-agent, then SSH signers if
+// they are set. The returned function must be called to release the
+// resources attached to the authentication method.
+func (g *Git) authMethod() (transport.AuthMethod, func(), error) {
+	if g.sshAgent {
+		hostKeyCallback, err := newKnownHostsCallback(g.knownHosts...)
+		if err != nil {
+			return nil, nil, err
 		}
-	} else {
-		auth = &githttp.BasicAuth{
-			Password: g.githubToken,
-			Username: g.githubUsername,
+		agentClient, conn, err := dialSSHAgent()
+		if err != nil {
+			return nil, nil, err
+		}
+		return &gitssh.PublicKeysCallback{
+			User:     defaultUser,
+			Callback: agentClient.Signers,
+			HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+				HostKeyCallback: hostKeyCallback,
+			},
+		}, func() { conn.Close() }, nil
+	}
+
+	signer := g.signer
+	if signer == nil && g.signerFunc != nil {
+		var err error
+		signer, err = g.signerFunc()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if signer != nil {
+		hostKeyCallback, err := newKnownHostsCallback(g.knownHosts...)
+		if err != nil {
+			return nil, nil, err
 		}
+		return &gitssh.PublicKeys{
+			User:   defaultUser,
+			Signer: signer,
+			HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+				HostKeyCallback: hostKeyCallback,
+			},
+		}, noopCloser, nil
 	}
-	_, err := git.PlainCloneContext(ctx, dest, false, &git.CloneOptions{
+	return &githttp.BasicAuth{
+		Password: g.githubToken,
+		Username: g.githubUsername,
+	}, noopCloser, nil
+}
+
+// Clone clones a remote git repository into a destination path. Clone will
+// prioritise the ssh-agent and SSH signer if they are set.
+func (g *Git) Clone(ctx context.Context, url, dest string) error {
+	auth, closeAuth, err := g.authMethod()
+	if err != nil {
+		return err
+	}
+	defer closeAuth()
+
+	_, err = git.PlainCloneContext(ctx, dest, false, &git.CloneOptions{
 		Auth:       auth,
 		URL:        url,
 		RemoteName: g.remote,
@@ -105,3 +188,52 @@ func (g *Git) Clone(ctx context.Context, url, dest string) error {
 func (g *Git) Open(path string) (*git.Repository, error) {
 	return git.PlainOpen(path)
 }
+
+// Fetch fetches the references and objects of the given repository remote.
+// It doesn't return an error if the remote is already up to date.
+func (g *Git) Fetch(ctx context.Context, repo *git.Repository, remote string) error {
+	auth, closeAuth, err := g.authMethod()
+	if err != nil {
+		return err
+	}
+	defer closeAuth()
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remote,
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
+
+// Push updates the references of the given repository remote using the
+// supplied refspecs. It doesn't return an error if the remote is already
+// up to date.
+func (g *Git) Push(ctx context.Context, repo *git.Repository, remote string, refSpecs ...string) error {
+	auth, closeAuth, err := g.authMethod()
+	if err != nil {
+		return err
+	}
+	defer closeAuth()
+
+	specs := make([]config.RefSpec, 0, len(refSpecs))
+	for _, refSpec := range refSpecs {
+		spec := config.RefSpec(refSpec)
+		if err := spec.Validate(); err != nil {
+			return err
+		}
+		specs = append(specs, spec)
+	}
+
+	err = repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   specs,
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
@@ -40,3 +40,29 @@ func WithSSHSigner(signer ssh.Signer) Option {
 		g.signer = signer
 	}
 }
+
+// WithSSHSignerFunc sets a function returning the ssh.Signer used to clone
+// repositories with ssh protocol. The function is called every time a
+// repository is cloned, it allows loading (and decrypting) the key lazily.
+func WithSSHSignerFunc(signerFunc func() (ssh.Signer, error)) Option {
+	return func(g *Git) {
+		g.signerFunc = signerFunc
+	}
+}
+
+// WithSSHAgent configures Git to authenticate using the keys of the ssh-agent
+// listening on SSH_AUTH_SOCK. The ssh-agent takes precedence over any SSH
+// signer.
+func WithSSHAgent() Option {
+	return func(g *Git) {
+		g.sshAgent = true
+	}
+}
+
+// WithKnownHosts sets the known_hosts files used to verify the remote host
+// keys when using ssh protocol. By default $HOME/.ssh/known_hosts is used.
+func WithKnownHosts(files ...string) Option {
+	return func(g *Git) {
+		g.knownHosts = files
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package git
+
+import (
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// AheadBehind returns the number of commits reachable from local that are
+// not reachable from upstream (ahead) and the number of commits reachable
+// from upstream that are not reachable from local (behind).
+func AheadBehind(repo *git.Repository, local, upstream plumbing.Hash) (ahead int, behind int, err error) {
+	if local == upstream {
+		return 0, 0, nil
+	}
+
+	localCommits, err := reachableCommits(repo, local)
+	if err != nil {
+		return 0, 0, err
+	}
+	upstreamCommits, err := reachableCommits(repo, upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for hash := range localCommits {
+		if _, ok := upstreamCommits[hash]; !ok {
+			ahead++
+		}
+	}
+	for hash := range upstreamCommits {
+		if _, ok := localCommits[hash]; !ok {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// reachableCommits returns the set of commits reachable from the given
+// commit hash, including itself.
+func reachableCommits(repo *git.Repository, from plumbing.Hash) (map[plumbing.Hash]struct{}, error) {
+	iter, err := repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	commits := make(map[plumbing.Hash]struct{})
+	err = iter.ForEach(func(c *object.Commit) error {
+		commits[c.Hash] = struct{}{}
+		return nil
+	})
+	if err != nil && err != storer.ErrStop {
+		return nil, err
+	}
+	return commits, nil
+}
@@ -0,0 +1,55 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/testutil"
+)
+
+func TestAheadBehind(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	repo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(err)
+	head, err := repo.Head()
+	require.NoError(err)
+	base := head.Hash()
+
+	require.NoError(testutil.CheckoutBranch(repo, "feature"))
+	first, err := testutil.CommitFile(repo, "a.txt", "a", "first")
+	require.NoError(err)
+	second, err := testutil.CommitFile(repo, "b.txt", "b", "second")
+	require.NoError(err)
+
+	ahead, behind, err := AheadBehind(repo, second, base)
+	require.NoError(err)
+	assert.Equal(2, ahead)
+	assert.Equal(0, behind)
+
+	ahead, behind, err = AheadBehind(repo, base, first)
+	require.NoError(err)
+	assert.Equal(0, ahead)
+	assert.Equal(1, behind)
+
+	ahead, behind, err = AheadBehind(repo, second, second)
+	require.NoError(err)
+	assert.Equal(0, ahead)
+	assert.Equal(0, behind)
+}
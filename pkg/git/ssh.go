@@ -0,0 +1,138 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sshAuthSockEnv = "SSH_AUTH_SOCK"
+)
+
+var (
+	ErrSSHAgentUnavailable = errors.New("ssh-agent unavailable")
+	ErrSSHAgentNoKeys      = errors.New("ssh-agent has no keys")
+	ErrUnknownHostKey      = errors.New("unknown host key")
+	ErrHostKeyMismatch     = errors.New("host key mismatch")
+	ErrHostKeyRevoked      = errors.New("host key revoked")
+)
+
+// dialSSHAgent connects to the ssh-agent listening on the socket pointed by
+// the SSH_AUTH_SOCK environment variable. The caller is responsible of closing
+// the returned connection.
+func dialSSHAgent() (agent.ExtendedAgent, io.Closer, error) {
+	socket := os.Getenv(sshAuthSockEnv)
+	if socket == "" {
+		return nil, nil, fmt.Errorf("%w: %s is not set", ErrSSHAgentUnavailable, sshAuthSockEnv)
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrSSHAgentUnavailable, err)
+	}
+	return agent.NewClient(conn), conn, nil
+}
+
+// CheckSSHAgent verifies that an ssh-agent is running and holds at least
+// one key.
+func CheckSSHAgent() error {
+	agentClient, conn, err := dialSSHAgent()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	keys, err := agentClient.List()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSSHAgentUnavailable, err)
+	}
+	if len(keys) == 0 {
+		return ErrSSHAgentNoKeys
+	}
+	return nil
+}
+
+// defaultKnownHostsFiles returns the default known_hosts file, located
+// in $HOME/.ssh/known_hosts
+func defaultKnownHostsFiles() ([]string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return []string{filepath.Join(home, ".ssh", "known_hosts")}, nil
+}
+
+// newKnownHostsCallback returns a ssh.HostKeyCallback verifying the remote
+// host keys against the given known_hosts files. If no files are given it
+// will use $HOME/.ssh/known_hosts. Unlike knownhosts.New, the returned
+// callback errors wrap ErrUnknownHostKey, ErrHostKeyMismatch or
+// ErrHostKeyRevoked and explain how to fix the problem.
+func newKnownHostsCallback(files ...string) (ssh.HostKeyCallback, error) {
+	if len(files) == 0 {
+		var err error
+		files, err = defaultKnownHostsFiles()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load known hosts files: %v", err)
+	}
+
+	knownHostsFiles := strings.Join(files, ", ")
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				return fmt.Errorf(
+					"%w: %s is not present in %s. You can add it with 'ssh-keyscan %s >> %s'",
+					ErrUnknownHostKey, hostname, knownHostsFiles, knownhosts.Normalize(hostname), files[0],
+				)
+			}
+			want := keyErr.Want[0]
+			return fmt.Errorf(
+				"%w: %s presented a %s key that doesn't match the one in %s:%d. "+
+					"Someone could be eavesdropping on you, or the host key has just been changed",
+				ErrHostKeyMismatch, hostname, key.Type(), want.Filename, want.Line,
+			)
+		}
+
+		var revokedErr *knownhosts.RevokedError
+		if errors.As(err, &revokedErr) {
+			return fmt.Errorf(
+				"%w: %s presented a key revoked in %s:%d",
+				ErrHostKeyRevoked, hostname, revokedErr.Revoked.Filename, revokedErr.Revoked.Line,
+			)
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,108 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build !windows
+
+package git
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func newTestPublicKey(t *testing.T) (*rsa.PrivateKey, ssh.PublicKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, pub
+}
+
+func TestNewKnownHostsCallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-knownhosts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, githubKey := newTestPublicKey(t)
+	_, otherKey := newTestPublicKey(t)
+
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{"github.com"}, githubKey)
+	require.NoError(t, ioutil.WriteFile(knownHostsPath, []byte(line+"\n"), 0600))
+
+	callback, err := newKnownHostsCallback(knownHostsPath)
+	require.NoError(t, err)
+
+	remote := &net.TCPAddr{IP: net.ParseIP("140.82.121.4"), Port: 22}
+	assert.NoError(t, callback("github.com:22", remote, githubKey))
+
+	err = callback("github.com:22", remote, otherKey)
+	assert.True(t, errors.Is(err, ErrHostKeyMismatch), "unexpected error: %v", err)
+
+	err = callback("gitlab.com:22", remote, githubKey)
+	assert.True(t, errors.Is(err, ErrUnknownHostKey), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "ssh-keyscan gitlab.com")
+
+	_, err = newKnownHostsCallback(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestCheckSSHAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	keyring := agent.NewKeyring()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+
+	oldSocket := os.Getenv(sshAuthSockEnv)
+	defer os.Setenv(sshAuthSockEnv, oldSocket)
+
+	os.Setenv(sshAuthSockEnv, "")
+	assert.True(t, errors.Is(CheckSSHAgent(), ErrSSHAgentUnavailable))
+
+	os.Setenv(sshAuthSockEnv, socket)
+	assert.Equal(t, ErrSSHAgentNoKeys, CheckSSHAgent())
+
+	key, _ := newTestPublicKey(t)
+	require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: key}))
+	assert.NoError(t, CheckSSHAgent())
+}
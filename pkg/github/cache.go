@@ -0,0 +1,208 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+	headerAuth        = "Authorization"
+
+	// defaultCacheMaxSize is the default maximum size of the cache directory.
+	defaultCacheMaxSize = 32 << 20
+	// cacheTempPrefix is the prefix of the cache entries being written.
+	cacheTempPrefix = ".tmp-"
+)
+
+// CacheTransport is an http.RoundTripper caching the responses of GET requests
+// on disk, and revalidating them using conditional requests (ETag/If-None-Match).
+// Github doesn't count the conditional requests answered with 304 Not Modified
+// against the rate limit.
+//
+// The cache size is bounded by MaxSize. When it's exceeded the least recently
+// used entries are evicted.
+type CacheTransport struct {
+	// Base is the transport used to make requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+	// Dir is the directory storing the cached responses.
+	Dir string
+	// MaxSize is the maximum size of the cached responses, in bytes. Zero
+	// means no limit.
+	MaxSize int64
+}
+
+// NewCacheTransport returns a CacheTransport storing up to 32MiB of responses
+// in dir.
+func NewCacheTransport(base http.RoundTripper, dir string) *CacheTransport {
+	return &CacheTransport{Base: base, Dir: dir, MaxSize: defaultCacheMaxSize}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get(headerIfNoneMatch) != "" {
+		return t.base().RoundTrip(req)
+	}
+
+	path := t.cachePath(req)
+	cached, err := t.load(path, req)
+	if err != nil {
+		// ignore unreadable cache entries, they are overwritten
+		cached = nil
+	}
+
+	r := req
+	if cached != nil {
+		r = req.Clone(req.Context())
+		r.Header.Set(headerIfNoneMatch, cached.Header.Get(headerETag))
+	}
+	resp, err := t.base().RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// keep the rate limit information up to date
+		for _, h := range []string{headerRateLimit, headerRateRemaining, headerRateReset} {
+			if v := resp.Header.Get(h); v != "" {
+				cached.Header.Set(h, v)
+			}
+		}
+		// mark the entry as recently used
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+		return cached, nil
+	}
+
+	if resp.StatusCode == http.StatusOK && resp.Header.Get(headerETag) != "" {
+		// a failure to write the cache doesn't fail the request
+		if err := t.store(path, resp); err == nil {
+			_ = t.evict()
+		}
+	}
+	return resp, nil
+}
+
+// cachePath returns the path of the cache entry of a request. Responses
+// depend on the caller credentials, the authorization header is part of the
+// key.
+func (t *CacheTransport) cachePath(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.String()))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get(headerAuth)))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Accept")))
+	return filepath.Join(t.Dir, hex.EncodeToString(h.Sum(nil)))
+}
+
+// load reads a cached response. It returns nil if the request isn't cached.
+func (t *CacheTransport) load(path string, req *http.Request) (*http.Response, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+}
+
+// store writes a response in the cache and replaces its body, which is
+// consumed.
+func (t *CacheTransport) store(path string, resp *http.Response) error {
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.Dir, 0700); err != nil {
+		return err
+	}
+
+	// write atomically, concurrent requests can share the same entry
+	tmp, err := ioutil.TempFile(t.Dir, cacheTempPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(dump); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// evict removes the least recently used entries until the cache size is lower
+// than MaxSize.
+func (t *CacheTransport) evict() error {
+	if t.MaxSize <= 0 {
+		return nil
+	}
+	infos, err := ioutil.ReadDir(t.Dir)
+	if err != nil {
+		return err
+	}
+
+	var entries []os.FileInfo
+	var size int64
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), cacheTempPrefix) {
+			continue
+		}
+		entries = append(entries, info)
+		size += info.Size()
+	}
+	if size <= t.MaxSize {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, entry := range entries {
+		if size <= t.MaxSize {
+			break
+		}
+		err := os.Remove(filepath.Join(t.Dir, entry.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		size -= entry.Size()
+	}
+	return nil
+}
+
+func (t *CacheTransport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
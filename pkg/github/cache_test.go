@@ -0,0 +1,179 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
+)
+
+func TestCacheTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-github-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var calls, notModified int32
+	remaining := int32(5000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(atomic.LoadInt32(&remaining)))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(atomic.AddInt32(&remaining, -1)))
+		if r.URL.Path == "/no-etag" {
+			fmt.Fprint(w, `{"name": "no-etag"}`)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprintf(w, `{"name": "s3-controller", "user": %q}`, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	transport := NewCacheTransport(http.DefaultTransport, dir)
+	get := func(path, auth string) (*http.Response, string) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	// first request is cached
+	resp, body := get("/repos/ack-bot/s3-controller", "token a")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"name": "s3-controller", "user": "token a"}`, body)
+	assert.Equal(t, "4999", resp.Header.Get("X-RateLimit-Remaining"))
+
+	// second request is revalidated
+	resp, body = get("/repos/ack-bot/s3-controller", "token a")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"name": "s3-controller", "user": "token a"}`, body)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+	// rate limit headers come from the 304 response
+	assert.Equal(t, "4999", resp.Header.Get("X-RateLimit-Remaining"))
+
+	// responses are cached per credentials
+	_, body = get("/repos/ack-bot/s3-controller", "token b")
+	assert.Equal(t, `{"name": "s3-controller", "user": "token b"}`, body)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+
+	// responses without ETag are not cached
+	get("/no-etag", "")
+	get("/no-etag", "")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+}
+
+func TestNewClient_cache(t *testing.T) {
+	tests := []struct {
+		name      string
+		newClient func(baseURL, cacheDir string) (*Client, error)
+	}{
+		{
+			name: "options",
+			newClient: func(baseURL, cacheDir string) (*Client, error) {
+				return NewClient("secret", WithCacheDirectory(cacheDir), WithBaseURL(baseURL, ""))
+			},
+		},
+		{
+			name: "configuration",
+			newClient: func(baseURL, cacheDir string) (*Client, error) {
+				return NewClientFromConfig(&config.GithubConfig{Token: "secret", BaseURL: baseURL}, cacheDir)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "ackdev-github-cache")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			var calls, notModified int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+				if r.Header.Get("If-None-Match") == `"abc"` {
+					atomic.AddInt32(&notModified, 1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"abc"`)
+				fmt.Fprint(w, `{"name": "s3-controller", "fork": true}`)
+			}))
+			defer server.Close()
+
+			c, err := tt.newClient(server.URL, dir)
+			require.NoError(t, err)
+
+			for i := 0; i < 2; i++ {
+				repo, err := c.GetRepository(context.Background(), "ack-bot", "s3-controller")
+				require.NoError(t, err)
+				assert.Equal(t, "s3-controller", repo.GetName())
+				assert.True(t, repo.GetFork())
+			}
+			assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+			assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+		})
+	}
+}
+
+func TestCacheTransport_evict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-github-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// entries from the least to the most recently used
+	now := time.Now()
+	for i, name := range []string{"a", "b", "c", ".tmp-d"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, make([]byte, 10), 0600))
+		mtime := now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	transport := &CacheTransport{Dir: dir, MaxSize: 25}
+	require.NoError(t, transport.evict())
+
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	// temporary files are ignored
+	assert.Equal(t, []string{".tmp-d", "b", "c"}, names)
+}
@@ -16,70 +16,212 @@ package github
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v35/github"
 	"golang.org/x/oauth2"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
 )
 
 var _ RepositoryService = &Client{}
 
-var ErrForkNotFound = errors.New("fork not found")
+var (
+	ErrForkNotFound = errors.New("fork not found")
+	// ErrForkNotReady is returned when a fork is still being created by Github
+	// after WaitForFork deadline.
+	ErrForkNotReady = errors.New("fork still being created")
+	// ErrForkNameTaken is returned when waiting for a fork whose name is used
+	// by another repository.
+	ErrForkNameTaken = errors.New("fork name taken by another repository")
+)
 
 const (
-	ACKOrg                = "aws-controllers-k8s"
-	defaultRequestTimeout = 10 * time.Second
+	ACKOrg = "aws-controllers-k8s"
+	// defaultCallTimeout bounds API calls, rate limit waits and retries
+	// included. Each attempt is bounded by the retry transport timeout.
+	defaultCallTimeout = 5 * time.Minute
+	// defaultForkWaitTimeout is the maximum duration WaitForFork waits for a
+	// fork when the context has no deadline.
+	defaultForkWaitTimeout = 2 * time.Minute
+)
+
+var (
+	// forkPollInitialInterval and forkPollMaxInterval bound the exponential
+	// backoff used to poll forks being created.
+	forkPollInitialInterval = 500 * time.Millisecond
+	forkPollMaxInterval     = 10 * time.Second
 )
 
-// NewClient takes a token and instantiate a new Client object
-func NewClient(token string) *Client {
-	ctx := context.TODO()
+// ClientOption is a function that configures a Client.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	cacheDir     string
+	baseURL      string
+	uploadURL    string
+	organization string
+}
+
+// WithBaseURL sets the Github API endpoints, to use Github Enterprise or any
+// Github compatible API. For example https://github.example.com/api/v3/. If
+// uploadURL is empty, baseURL is used.
+func WithBaseURL(baseURL, uploadURL string) ClientOption {
+	return func(o *clientOptions) {
+		o.baseURL = baseURL
+		o.uploadURL = uploadURL
+	}
+}
+
+// WithOrganization sets the organisation owning the upstream repositories.
+// The default organisation is ACKOrg.
+func WithOrganization(organization string) ClientOption {
+	return func(o *clientOptions) {
+		o.organization = organization
+	}
+}
+
+// WithCacheDirectory caches the responses of GET requests in dir. Cached
+// responses are revalidated using conditional requests, which don't count
+// against the Github rate limit.
+func WithCacheDirectory(dir string) ClientOption {
+	return func(o *clientOptions) {
+		o.cacheDir = dir
+	}
+}
+
+// NewClient takes a token and instantiate a new Client object. Requests failing
+// because of server errors or rate limits are retried.
+func NewClient(token string, opts ...ClientOption) (*Client, error) {
+	options := &clientOptions{organization: ACKOrg}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var transport http.RoundTripper = NewRetryTransport(http.DefaultTransport)
+	if options.cacheDir != "" {
+		transport = NewCacheTransport(transport, options.cacheDir)
+	}
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	oc := oauth2.NewClient(ctx, ts)
-	return &Client{github.NewClient(oc)}
+	oc := &http.Client{
+		Transport: &oauth2.Transport{Source: ts, Base: transport},
+	}
+
+	ghc := github.NewClient(oc)
+	if options.baseURL != "" {
+		baseURL, err := parseEndpoint(options.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Github base URL: %v", err)
+		}
+		uploadURL := baseURL
+		if options.uploadURL != "" {
+			uploadURL, err = parseEndpoint(options.uploadURL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Github upload URL: %v", err)
+			}
+		}
+		ghc.BaseURL, ghc.UploadURL = baseURL, uploadURL
+	}
+	return &Client{Client: ghc, organization: options.organization}, nil
+}
+
+// NewClientFromConfig returns a Client authenticated with the configured token
+// and using the configured endpoints and upstream organisation. If cacheDir is
+// not empty, the API responses are cached in it.
+func NewClientFromConfig(cfg *config.GithubConfig, cacheDir string) (*Client, error) {
+	return NewClient(
+		cfg.Token,
+		WithBaseURL(cfg.BaseURL, cfg.UploadURL),
+		WithOrganization(cfg.UpstreamOrg),
+		WithCacheDirectory(cacheDir),
+	)
+}
+
+// parseEndpoint parses an API endpoint URL, and adds the trailing slash
+// needed to resolve the API paths.
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an absolute URL", endpoint)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u, nil
 }
 
 // RepositoryService is the interface implemented by the Github client wrapper. It exposes
 // functionalities to simplify the interactions with the repository endpoint of Github APIv3
 type RepositoryService interface {
-	ForkRepository(ctx context.Context, repoName string) error
+	ForkRepository(ctx context.Context, owner, repoName string) (*github.Repository, error)
 	RenameRepository(ctx context.Context, owner, name, newName string) error
 	GetRepository(ctx context.Context, owner, repoName string) (*github.Repository, error)
-	ListRepositoryForks(ctx context.Context, repoName string) ([]*github.Repository, error)
-	GetUserRepositoryFork(ctx context.Context, owner, repoName string) (*github.Repository, error)
+	ListRepositoryForks(ctx context.Context, owner, repoName string) ([]*github.Repository, error)
+	GetUserRepositoryFork(ctx context.Context, owner, upstreamOwner, repoName, expectedForkName string) (*github.Repository, error)
+	WaitForFork(ctx context.Context, owner, upstreamOwner, repoName, forkName string) (*github.Repository, error)
 }
 
 // Client is a github.Client wrapper
 type Client struct {
 	*github.Client
+
+	// organization owns the upstream repositories
+	organization string
+}
+
+// Organization returns the organisation owning the upstream repositories.
+func (c *Client) Organization() string {
+	if c.organization == "" {
+		return ACKOrg
+	}
+	return c.organization
 }
 
-// ForkRepository forks a Github repository from the ACK organisation.
-func (c *Client) ForkRepository(ctx context.Context, repoName string) error {
-	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+// upstreamOwner returns owner, or the client organisation if owner is empty.
+func (c *Client) upstreamOwner(owner string) string {
+	if owner == "" {
+		return c.Organization()
+	}
+	return owner
+}
+
+// ForkRepository forks the Github repository owner/repoName. If owner is empty
+// the repository is forked from the upstream organisation. It returns the fork,
+// which Github names differently from the upstream repository if the name is
+// already taken. The fork is created asynchronously, use WaitForFork before
+// using it.
+func (c *Client) ForkRepository(ctx context.Context, owner, repoName string) (*github.Repository, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
 	defer cancel()
 
 	opt := &github.RepositoryCreateForkOptions{}
-	_, _, err := c.Client.Repositories.CreateFork(ctx, ACKOrg, repoName, opt)
+	fork, _, err := c.Client.Repositories.CreateFork(ctx, c.upstreamOwner(owner), repoName, opt)
 	if err != nil {
 		// AcceptedError occurs when GitHub returns 202 Accepted response with an
 		// empty body, which means a job was scheduled on the GitHub side to process
 		// the information needed and cache it.
 		// https://github.com/google/go-github/blob/master/github/github.go#L699-L704
 		if _, ok := err.(*github.AcceptedError); ok {
-			return nil
+			return fork, nil
 		}
-		return err
+		return nil, err
 	}
-	return nil
+	return fork, nil
 }
 
 // RenameRepository renames a Github repository. The request should have admin access on the
 // target repositories to be able to rename it.
 func (c *Client) RenameRepository(ctx context.Context, owner, name, newName string) error {
-	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
 	defer cancel()
 
 	opt := &github.Repository{
@@ -94,7 +236,7 @@ func (c *Client) RenameRepository(ctx context.Context, owner, name, newName stri
 
 // GetRepository takes an owner and repoName and returns the Github repository informations
 func (c *Client) GetRepository(ctx context.Context, owner, repoName string) (*github.Repository, error) {
-	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
 	defer cancel()
 
 	repo, _, err := c.Client.Repositories.Get(ctx, owner, repoName)
@@ -104,10 +246,11 @@ func (c *Client) GetRepository(ctx context.Context, owner, repoName string) (*gi
 	return repo, nil
 }
 
-// ListRepositoryForks list the forks of a given repository in the ACK organisation. It returns
-// a list fork information which includes the owner and the fork name (forkInfo).
-func (c *Client) ListRepositoryForks(ctx context.Context, repoName string) ([]*github.Repository, error) {
-	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
+// ListRepositoryForks list the forks of the repository owner/repoName, or of repoName in the upstream
+// organisation if owner is empty. It returns a list fork information which includes the owner and
+// the fork name (forkInfo).
+func (c *Client) ListRepositoryForks(ctx context.Context, owner, repoName string) ([]*github.Repository, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
 	defer cancel()
 
 	var forks []*github.Repository
@@ -129,7 +272,7 @@ func (c *Client) ListRepositoryForks(ctx context.Context, repoName string) ([]*g
 			},
 		}
 
-		repos, resp, err = c.Client.Repositories.ListForks(ctx, ACKOrg, repoName, opt)
+		repos, resp, err = c.Client.Repositories.ListForks(ctx, c.upstreamOwner(owner), repoName, opt)
 		if err != nil {
 			return nil, err
 		}
@@ -140,20 +283,189 @@ func (c *Client) ListRepositoryForks(ctx context.Context, repoName string) ([]*g
 	return forks, nil
 }
 
-// GetUserRepositoryFork takes an ACK repository name and tries to find it fork in the user public repositories.
-func (c *Client) GetUserRepositoryFork(ctx context.Context, owner string, repoName string) (*github.Repository, error) {
-	ctx, cancel := context.WithTimeout(ctx, defaultRequestTimeout)
-	defer cancel()
+// GetUserRepositoryFork takes an upstream repository and returns its fork owned by the user. The
+// fork is first looked up using the expected fork name and the repository name, then among all the
+// forks owned by the authenticated user, which finds forks renamed to anything else. Forks whose
+// name contains one of the expected names are checked first. Only repositories whose parent is
+// upstreamOwner/repoName are considered as its fork. If upstreamOwner is empty the upstream
+// organisation is used.
+func (c *Client) GetUserRepositoryFork(
+	ctx context.Context,
+	owner string,
+	upstreamOwner string,
+	repoName string,
+	expectedForkName string,
+) (*github.Repository, error) {
+	upstreamOwner = c.upstreamOwner(upstreamOwner)
+	checked := map[string]bool{}
+	// getFork returns the repository owner/name if it's a fork of the upstream
+	// repository, nil otherwise.
+	getFork := func(name string) (*github.Repository, error) {
+		if checked[name] {
+			return nil, nil
+		}
+		checked[name] = true
+
+		repo, err := c.GetRepository(ctx, owner, name)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if !isForkOf(repo, upstreamOwner, repoName) {
+			return nil, nil
+		}
+		return repo, nil
+	}
 
-	repos, err := c.ListRepositoryForks(ctx, repoName)
+	for _, name := range []string{expectedForkName, repoName} {
+		if name == "" {
+			continue
+		}
+		fork, err := getFork(name)
+		if err != nil || fork != nil {
+			return fork, err
+		}
+	}
+
+	// the fork was renamed, look for it in the user forks. Listed
+	// repositories don't contain their parent, each fork is fetched, the
+	// ones named like the repository first.
+	repos, err := c.listUserRepositories(ctx)
 	if err != nil {
 		return nil, err
 	}
-
+	var likely, others []*github.Repository
 	for _, repo := range repos {
-		if *repo.Owner.Login == owner {
-			return repo, nil
+		if !repo.GetFork() || checked[repo.GetName()] {
+			continue
+		}
+		if login := repo.GetOwner().GetLogin(); login != "" && !strings.EqualFold(login, owner) {
+			continue
+		}
+		if nameContainsAny(repo.GetName(), repoName, expectedForkName) {
+			likely = append(likely, repo)
+		} else {
+			others = append(others, repo)
+		}
+	}
+	for _, repo := range append(likely, others...) {
+		fork, err := getFork(repo.GetName())
+		if err != nil || fork != nil {
+			return fork, err
 		}
 	}
 	return nil, ErrForkNotFound
 }
+
+// nameContainsAny returns true if name contains one of the non empty
+// substrings, ignoring case.
+func nameContainsAny(name string, substrings ...string) bool {
+	name = strings.ToLower(name)
+	for _, sub := range substrings {
+		if sub != "" && strings.Contains(name, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// listUserRepositories lists the repositories owned by the authenticated user,
+// private ones included.
+func (c *Client) listUserRepositories(ctx context.Context) ([]*github.Repository, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
+	defer cancel()
+
+	var all []*github.Repository
+	opt := &github.RepositoryListOptions{
+		Affiliation: "owner",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		repos, resp, err := c.Client.Repositories.List(ctx, "", opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return all, nil
+}
+
+// isForkOf returns true if repo is a fork of owner/name.
+func isForkOf(repo *github.Repository, owner, name string) bool {
+	if !repo.GetFork() || repo.Parent == nil {
+		return false
+	}
+	// Github logins and repository names are case insensitive
+	return strings.EqualFold(repo.Parent.GetOwner().GetLogin(), owner) && strings.EqualFold(repo.Parent.GetName(), name)
+}
+
+// WaitForFork waits until a fork created by ForkRepository is available. Github
+// creates forks asynchronously, the fork is polled with an exponential backoff
+// until it exists or ctx is done. If ctx has no deadline, WaitForFork gives up
+// after two minutes. An error wrapping ErrForkNotReady is returned if the fork
+// isn't available in time. If owner/forkName exists but isn't a fork of
+// upstreamOwner/repoName, an error wrapping ErrForkNameTaken is returned right
+// away. If upstreamOwner is empty the upstream organisation is used.
+func (c *Client) WaitForFork(ctx context.Context, owner, upstreamOwner, repoName, forkName string) (*github.Repository, error) {
+	upstreamOwner = c.upstreamOwner(upstreamOwner)
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultForkWaitTimeout)
+		defer cancel()
+	}
+
+	interval := forkPollInitialInterval
+	for {
+		repo, err := c.GetRepository(ctx, owner, forkName)
+		if err == nil {
+			if !isForkOf(repo, upstreamOwner, repoName) {
+				return nil, fmt.Errorf("%w: %s/%s is not a fork of %s/%s", ErrForkNameTaken, owner, forkName, upstreamOwner, repoName)
+			}
+			return repo, nil
+		}
+		// only retry while the fork doesn't exist yet
+		if err != nil && !isNotFound(err) && ctx.Err() == nil {
+			return nil, err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %s/%s is not available: %v", ErrForkNotReady, owner, forkName, ctx.Err())
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > forkPollMaxInterval {
+			interval = forkPollMaxInterval
+		}
+	}
+}
+
+// isNotFound returns true if err is a Github API 404 response.
+func isNotFound(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
+}
+
+// RateLimit returns the core API rate limit of the authenticated user. This
+// call doesn't count against the rate limit.
+func (c *Client) RateLimit(ctx context.Context) (*github.Rate, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultCallTimeout)
+	defer cancel()
+
+	limits, _, err := c.Client.RateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return limits.GetCore(), nil
+}
@@ -0,0 +1,448 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a Client talking to a fake Github API server. The
+// returned function closes the server.
+func newTestClient(t *testing.T, handler http.Handler, opts ...ClientOption) (*Client, func()) {
+	server := httptest.NewServer(handler)
+
+	c, err := NewClient("", append([]ClientOption{WithBaseURL(server.URL, "")}, opts...)...)
+	require.NoError(t, err)
+	return c, server.Close
+}
+
+func TestClient_WaitForFork(t *testing.T) {
+	forkPollInitialInterval = time.Millisecond
+	forkPollMaxInterval = 5 * time.Millisecond
+	defer func() {
+		forkPollInitialInterval = 500 * time.Millisecond
+		forkPollMaxInterval = 10 * time.Second
+	}()
+
+	tests := []struct {
+		name string
+		// readyAfter is the number of 404 responses before the fork is found,
+		// -1 means never.
+		readyAfter int32
+		status     int
+		// repo is the JSON representation of the ready repository
+		repo      string
+		timeout   time.Duration
+		wantErr   error
+		wantCalls int32
+	}{
+		{
+			name:       "fork ready",
+			readyAfter: 0,
+			timeout:    time.Second,
+			wantCalls:  1,
+		},
+		{
+			name:       "fork ready after polling",
+			readyAfter: 3,
+			timeout:    time.Second,
+			wantCalls:  4,
+		},
+		{
+			name:       "fork still being created",
+			readyAfter: -1,
+			timeout:    50 * time.Millisecond,
+			wantErr:    ErrForkNotReady,
+		},
+		{
+			name:       "name taken by a repository",
+			readyAfter: 0,
+			repo:       `{"name": "s3-controller", "fork": false}`,
+			timeout:    time.Second,
+			wantErr:    ErrForkNameTaken,
+			wantCalls:  1,
+		},
+		{
+			name:       "name taken by a fork of another repository",
+			readyAfter: 0,
+			repo:       `{"name": "s3-controller", "fork": true, "parent": {"name": "s3-controller", "owner": {"login": "someone"}}}`,
+			timeout:    time.Second,
+			wantErr:    ErrForkNameTaken,
+			wantCalls:  1,
+		},
+		{
+			name:      "unexpected error",
+			status:    http.StatusUnauthorized,
+			timeout:   time.Second,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			c, closeServer := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/repos/ack-bot/s3-controller", r.URL.Path)
+				n := atomic.AddInt32(&calls, 1)
+				switch {
+				case tt.status != 0:
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, `{"message": "Bad credentials"}`)
+				case tt.readyAfter < 0 || n <= tt.readyAfter:
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message": "Not Found"}`)
+				case tt.repo != "":
+					fmt.Fprint(w, tt.repo)
+				default:
+					fmt.Fprint(w, `{"name": "s3-controller", "fork": true, "parent": {"name": "s3-controller", "owner": {"login": "aws-controllers-k8s"}}}`)
+				}
+			}))
+			defer closeServer()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			repo, err := c.WaitForFork(ctx, "ack-bot", "", "s3-controller", "s3-controller")
+			switch {
+			case tt.wantErr != nil:
+				assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+			case tt.status != 0:
+				assert.Error(t, err)
+				assert.False(t, errors.Is(err, ErrForkNotReady))
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "s3-controller", repo.GetName())
+			}
+			if tt.wantCalls > 0 {
+				assert.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+			}
+		})
+	}
+}
+
+func TestClient_GetUserRepositoryFork(t *testing.T) {
+	fork := func(name, parentOwner, parentName string) string {
+		return fmt.Sprintf(
+			`{"name": %q, "owner": {"login": "ack-bot"}, "fork": true, "parent": {"name": %q, "owner": {"login": %q}}}`,
+			name, parentName, parentOwner,
+		)
+	}
+	notFork := func(name string) string {
+		return fmt.Sprintf(`{"name": %q, "owner": {"login": "ack-bot"}, "fork": false}`, name)
+	}
+
+	tests := []struct {
+		name string
+		// upstreamOwner is empty for repositories of the upstream organisation
+		upstreamOwner string
+		// repos maps the user repositories names to their JSON representation
+		repos    map[string]string
+		wantName string
+		wantErr  error
+		// wantPaths are the requested paths, in order
+		wantPaths []string
+	}{
+		{
+			name: "fork with the expected name",
+			repos: map[string]string{
+				"ack-s3-controller": fork("ack-s3-controller", ACKOrg, "s3-controller"),
+			},
+			wantName:  "ack-s3-controller",
+			wantPaths: []string{"/repos/ack-bot/ack-s3-controller"},
+		},
+		{
+			name: "fork with the upstream name",
+			repos: map[string]string{
+				"s3-controller": fork("s3-controller", ACKOrg, "s3-controller"),
+			},
+			wantName: "s3-controller",
+			wantPaths: []string{
+				"/repos/ack-bot/ack-s3-controller",
+				"/repos/ack-bot/s3-controller",
+			},
+		},
+		{
+			name: "renamed fork",
+			repos: map[string]string{
+				"s3-controller":    notFork("s3-controller"),
+				"other-fork":       fork("other-fork", ACKOrg, "ecr-controller"),
+				"my-s3-controller": fork("my-s3-controller", ACKOrg, "s3-controller"),
+				"not-a-fork-too":   notFork("not-a-fork-too"),
+			},
+			wantName: "my-s3-controller",
+			wantPaths: []string{
+				"/repos/ack-bot/ack-s3-controller",
+				"/repos/ack-bot/s3-controller",
+				"/user/repos",
+				"/repos/ack-bot/my-s3-controller",
+			},
+		},
+		{
+			name: "renamed fork with an unrelated name",
+			repos: map[string]string{
+				"other-fork": fork("other-fork", ACKOrg, "ecr-controller"),
+				"my-s3":      fork("my-s3", ACKOrg, "s3-controller"),
+			},
+			wantName: "my-s3",
+			wantPaths: []string{
+				"/repos/ack-bot/ack-s3-controller",
+				"/repos/ack-bot/s3-controller",
+				"/user/repos",
+				"/repos/ack-bot/my-s3",
+			},
+		},
+		{
+			name: "forks named like the repository are checked first",
+			repos: map[string]string{
+				"a-fork":          fork("a-fork", ACKOrg, "s3-controller"),
+				"b-fork":          fork("b-fork", ACKOrg, "ecr-controller"),
+				"s3-controller-1": fork("s3-controller-1", "someone", "s3-controller"),
+				"s3-controller-2": fork("s3-controller-2", "someone", "s3-controller"),
+			},
+			wantName: "a-fork",
+			wantPaths: []string{
+				"/repos/ack-bot/ack-s3-controller",
+				"/repos/ack-bot/s3-controller",
+				"/user/repos",
+				"/repos/ack-bot/s3-controller-1",
+				"/repos/ack-bot/s3-controller-2",
+				"/repos/ack-bot/a-fork",
+			},
+		},
+		{
+			name: "parent compared ignoring case",
+			repos: map[string]string{
+				"ack-s3-controller": fork("ack-s3-controller", "AWS-Controllers-K8s", "S3-Controller"),
+			},
+			wantName:  "ack-s3-controller",
+			wantPaths: []string{"/repos/ack-bot/ack-s3-controller"},
+		},
+		{
+			name: "fork of another organisation",
+			repos: map[string]string{
+				"ack-s3-controller": fork("ack-s3-controller", "someone", "s3-controller"),
+			},
+			wantErr: ErrForkNotFound,
+			wantPaths: []string{
+				"/repos/ack-bot/ack-s3-controller",
+				"/repos/ack-bot/s3-controller",
+				"/user/repos",
+			},
+		},
+		{
+			name:          "fork of a custom upstream owner",
+			upstreamOwner: "someone",
+			repos: map[string]string{
+				"ack-s3-controller": fork("ack-s3-controller", "someone", "s3-controller"),
+			},
+			wantName:  "ack-s3-controller",
+			wantPaths: []string{"/repos/ack-bot/ack-s3-controller"},
+		},
+		{
+			name:    "no fork",
+			repos:   map[string]string{},
+			wantErr: ErrForkNotFound,
+			wantPaths: []string{
+				"/repos/ack-bot/ack-s3-controller",
+				"/repos/ack-bot/s3-controller",
+				"/user/repos",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			c, closeServer := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if r.URL.Path == "/user/repos" {
+					assert.Equal(t, "owner", r.URL.Query().Get("affiliation"))
+					// the listed repositories don't contain their parent
+					names := make([]string, 0, len(tt.repos))
+					for name := range tt.repos {
+						names = append(names, name)
+					}
+					sort.Strings(names)
+					fmt.Fprint(w, "[")
+					for i, name := range names {
+						if i > 0 {
+							fmt.Fprint(w, ",")
+						}
+						fmt.Fprintf(w, `{"name": %q, "owner": {"login": "ack-bot"}, "fork": %t}`, name, strings.Contains(tt.repos[name], `"fork": true`))
+					}
+					fmt.Fprint(w, "]")
+					return
+				}
+				name := strings.TrimPrefix(r.URL.Path, "/repos/ack-bot/")
+				repo, ok := tt.repos[name]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"message": "Not Found"}`)
+					return
+				}
+				fmt.Fprint(w, repo)
+			}))
+			defer closeServer()
+
+			repo, err := c.GetUserRepositoryFork(context.Background(), "ack-bot", tt.upstreamOwner, "s3-controller", "ack-s3-controller")
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantName, repo.GetName())
+			}
+			// the forks of the ACK repository are never listed
+			assert.Equal(t, tt.wantPaths, paths)
+		})
+	}
+}
+
+func TestNewClient_endpoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		baseURL       string
+		uploadURL     string
+		wantBaseURL   string
+		wantUploadURL string
+		wantErr       bool
+	}{
+		{
+			name:          "default endpoints",
+			wantBaseURL:   "https://api.github.com/",
+			wantUploadURL: "https://uploads.github.com/",
+		},
+		{
+			name:          "enterprise endpoints",
+			baseURL:       "https://ghe.example.com/api/v3",
+			uploadURL:     "https://ghe.example.com/api/uploads/",
+			wantBaseURL:   "https://ghe.example.com/api/v3/",
+			wantUploadURL: "https://ghe.example.com/api/uploads/",
+		},
+		{
+			name:          "upload URL defaults to the base URL",
+			baseURL:       "http://127.0.0.1:8080/",
+			wantBaseURL:   "http://127.0.0.1:8080/",
+			wantUploadURL: "http://127.0.0.1:8080/",
+		},
+		{
+			name:    "relative base URL",
+			baseURL: "ghe.example.com/api/v3",
+			wantErr: true,
+		},
+		{
+			name:      "invalid upload URL",
+			baseURL:   "https://ghe.example.com/api/v3",
+			uploadURL: "://",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient("token", WithBaseURL(tt.baseURL, tt.uploadURL))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBaseURL, c.BaseURL.String())
+			assert.Equal(t, tt.wantUploadURL, c.UploadURL.String())
+		})
+	}
+}
+
+func TestClient_ForkRepository_organization(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ClientOption
+		owner    string
+		wantPath string
+	}{
+		{
+			name:     "default organisation",
+			wantPath: "/repos/aws-controllers-k8s/s3-controller/forks",
+		},
+		{
+			name:     "custom organisation",
+			opts:     []ClientOption{WithOrganization("my-org")},
+			wantPath: "/repos/my-org/s3-controller/forks",
+		},
+		{
+			name:     "repository owner",
+			opts:     []ClientOption{WithOrganization("my-org")},
+			owner:    "pre-release-org",
+			wantPath: "/repos/pre-release-org/s3-controller/forks",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			c, closeServer := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				assert.Equal(t, http.MethodPost, r.Method)
+				// forks are created asynchronously
+				w.WriteHeader(http.StatusAccepted)
+				fmt.Fprint(w, `{"name": "s3-controller-1", "fork": true}`)
+			}), tt.opts...)
+			defer closeServer()
+
+			fork, err := c.ForkRepository(context.Background(), tt.owner, "s3-controller")
+			require.NoError(t, err)
+			// the fork name is chosen by Github
+			assert.Equal(t, "s3-controller-1", fork.GetName())
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
+
+func TestClient_rateLimitRetries(t *testing.T) {
+	var waits []time.Duration
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	defer func() { sleep = sleepContext }()
+
+	var calls int32
+	c, closeServer := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			// secondary rate limit
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit."}`)
+		case 2:
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, `{"name": "s3-controller"}`)
+		}
+	}))
+	defer closeServer()
+
+	repo, err := c.GetRepository(context.Background(), "ack-bot", "s3-controller")
+	require.NoError(t, err)
+	assert.Equal(t, "s3-controller", repo.GetName())
+	// waits longer than an attempt timeout are honoured
+	assert.Equal(t, []time.Duration{time.Minute, 30 * time.Second}, waits)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
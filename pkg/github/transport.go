@@ -0,0 +1,272 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerRetryAfter    = "Retry-After"
+	headerRateLimit     = "X-RateLimit-Limit"
+	headerRateRemaining = "X-RateLimit-Remaining"
+	headerRateReset     = "X-RateLimit-Reset"
+
+	defaultMaxRetries = 3
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 30 * time.Second
+	// defaultMaxWait is the longest duration the transport waits for a rate
+	// limit to be reset before giving up.
+	defaultMaxWait = time.Minute
+	// defaultAttemptTimeout bounds each attempt of a request, waits between
+	// retries excluded.
+	defaultAttemptTimeout = 10 * time.Second
+)
+
+// sleep waits for d or until ctx is done. It's replaced in tests.
+var sleep = sleepContext
+
+// RetryTransport is an http.RoundTripper retrying the requests that failed
+// because of Github server errors (5xx) or rate limits. Waits between retries
+// honour the Retry-After and X-RateLimit-Reset headers, or follow an
+// exponential backoff. Server errors are only retried for idempotent requests.
+type RetryTransport struct {
+	// Base is the transport used to make requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+	// MaxRetries is the maximum number of retries of a request.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential backoff between retries.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxWait is the longest duration waited before a retry. Responses asking
+	// to wait longer are returned as is.
+	MaxWait time.Duration
+	// Timeout bounds each attempt, until its response body is closed. The
+	// request context bounds the whole request, retries included. Zero means
+	// no timeout.
+	Timeout time.Duration
+
+	// now is used to compute the waits from X-RateLimit-Reset headers
+	now func() time.Time
+}
+
+// NewRetryTransport returns a RetryTransport using base and the default retry
+// settings.
+func NewRetryTransport(base http.RoundTripper) *RetryTransport {
+	return &RetryTransport{
+		Base:       base,
+		MaxRetries: defaultMaxRetries,
+		MinBackoff: defaultMinBackoff,
+		MaxBackoff: defaultMaxBackoff,
+		MaxWait:    defaultMaxWait,
+		Timeout:    defaultAttemptTimeout,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.MinBackoff
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			var err error
+			if r, err = rewindRequest(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.roundTripAttempt(r)
+		if err != nil || attempt >= t.MaxRetries {
+			return resp, err
+		}
+
+		wait, retry := t.retryAfter(req, resp, backoff)
+		if !retry || wait > t.MaxWait || !canRewind(req) {
+			return resp, nil
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < wait {
+			// the request can't be retried in time
+			return resp, nil
+		}
+
+		// drain the body to reuse the connection
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		backoff *= 2
+		if backoff > t.MaxBackoff {
+			backoff = t.MaxBackoff
+		}
+	}
+}
+
+// roundTripAttempt makes a single attempt of a request, bounded by the
+// transport timeout.
+func (t *RetryTransport) roundTripAttempt(req *http.Request) (*http.Response, error) {
+	if t.Timeout <= 0 {
+		return t.base().RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.Timeout)
+	resp, err := t.base().RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the body is read after RoundTrip returns, the context is cancelled once
+	// the body is closed
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelReadCloser is an io.ReadCloser cancelling a context when it's closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer.
+func (c *cancelReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// retryAfter returns how long to wait before retrying a request, and whether
+// it should be retried at all.
+func (t *RetryTransport) retryAfter(req *http.Request, resp *http.Response, backoff time.Duration) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		if wait, ok := parseRetryAfter(resp.Header.Get(headerRetryAfter), t.clock()); ok {
+			return wait, true
+		}
+		// primary rate limit exceeded, wait for the reset
+		if resp.Header.Get(headerRateRemaining) == "0" {
+			reset, err := strconv.ParseInt(resp.Header.Get(headerRateReset), 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			wait := time.Unix(reset, 0).Sub(t.clock())
+			if wait < 0 {
+				wait = 0
+			}
+			return wait, true
+		}
+		if isSecondaryRateLimit(resp) {
+			return backoff, true
+		}
+		return 0, false
+	case resp.StatusCode >= http.StatusInternalServerError && isIdempotent(req.Method):
+		if wait, ok := parseRetryAfter(resp.Header.Get(headerRetryAfter), t.clock()); ok {
+			return wait, true
+		}
+		return backoff, true
+	}
+	return 0, false
+}
+
+func (t *RetryTransport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *RetryTransport) clock() time.Time {
+	if t.now == nil {
+		return time.Now()
+	}
+	return t.now()
+}
+
+// isSecondaryRateLimit returns true if a 403 response is caused by a secondary
+// (formerly abuse) rate limit. The response body is restored after inspection.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse")
+}
+
+// parseRetryAfter parses a Retry-After header value, which is either a number
+// of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// isIdempotent returns true for the HTTP methods that can safely be retried.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// canRewind returns true if the request body can be sent again.
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewindRequest returns a copy of req with a fresh body.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
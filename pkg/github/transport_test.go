@@ -0,0 +1,272 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTransport(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		method string
+		// responses are the successive responses of the server, the last one
+		// is repeated.
+		responses  []func(w http.ResponseWriter)
+		maxWait    time.Duration
+		wantStatus int
+		wantCalls  int32
+	}{
+		{
+			name:   "no retry on success",
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { fmt.Fprint(w, "ok") },
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  1,
+		},
+		{
+			name:   "server errors are retried",
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+				func(w http.ResponseWriter) { fmt.Fprint(w, "ok") },
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+		},
+		{
+			name:   "server errors are not retried for non idempotent requests",
+			method: http.MethodPost,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+			},
+			wantStatus: http.StatusBadGateway,
+			wantCalls:  1,
+		},
+		{
+			name:   "retries are bounded",
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  4,
+		},
+		{
+			name:   "secondary rate limit with Retry-After",
+			method: http.MethodPost,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusAccepted) },
+			},
+			wantStatus: http.StatusAccepted,
+			wantCalls:  2,
+		},
+		{
+			name:   "secondary rate limit message",
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit."}`)
+				},
+				func(w http.ResponseWriter) { fmt.Fprint(w, "ok") },
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:   "primary rate limit reset",
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Unix(), 10))
+					w.WriteHeader(http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) { fmt.Fprint(w, "ok") },
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:   "rate limit reset too far",
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+					w.WriteHeader(http.StatusForbidden)
+				},
+			},
+			wantStatus: http.StatusForbidden,
+			wantCalls:  1,
+		},
+		{
+			name:   "other client errors are not retried",
+			method: http.MethodGet,
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `{"message": "Must have admin rights to Repository."}`)
+				},
+			},
+			wantStatus: http.StatusForbidden,
+			wantCalls:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&calls, 1))
+				if r.Method == http.MethodPost {
+					body, _ := ioutil.ReadAll(r.Body)
+					assert.Equal(t, `{"name":"s3"}`, string(body))
+				}
+				if n > len(tt.responses) {
+					n = len(tt.responses)
+				}
+				tt.responses[n-1](w)
+			}))
+			defer server.Close()
+
+			transport := NewRetryTransport(http.DefaultTransport)
+			transport.MinBackoff = time.Millisecond
+			transport.MaxBackoff = 2 * time.Millisecond
+			transport.now = func() time.Time { return now }
+
+			var body *strings.Reader
+			if tt.method == http.MethodPost {
+				body = strings.NewReader(`{"name":"s3"}`)
+			}
+			req, err := http.NewRequest(tt.method, server.URL, nil)
+			if body != nil {
+				req, err = http.NewRequest(tt.method, server.URL, body)
+			}
+			require.NoError(t, err)
+
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestRetryTransport_ContextDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	resp, err := NewRetryTransport(http.DefaultTransport).RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	// the retry can't happen before the deadline, the response is returned
+	// right away
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetryTransport_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	transport := NewRetryTransport(http.DefaultTransport)
+	transport.Timeout = 200 * time.Millisecond
+
+	// the body is readable once RoundTrip returned
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "ok", string(body))
+
+	// attempts are bounded by the timeout, even if the request context has no
+	// deadline
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/slow", nil)
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = transport.RoundTrip(req)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+	assert.True(t, time.Since(start) < 2*time.Second)
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		want     time.Duration
+		wantBool bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"Tue, 01 Jun 2021 12:00:30 GMT", 30 * time.Second, true},
+		{"Tue, 01 Jun 2021 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+		{"-1", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantBool, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
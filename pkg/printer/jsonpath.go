@@ -0,0 +1,327 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed JSONPath template, following the syntax used by
+// kubectl. A template is made of literal text and actions enclosed in
+// braces. Supported actions are:
+//   - paths: {.items[*].name}, {.items[0]['name']}, {.}
+//   - string literals: {"\n"}
+//   - ranges: {range .items[*]}{.name}{"\n"}{end}
+//
+// Paths support field selection (.field or ['field']), array indexes
+// (negative indexes count from the end) and the [*] wildcard.
+type JSONPath struct {
+	nodes []jsonPathNode
+}
+
+// jsonPathNode is a node of a parsed JSONPath template: literal text, a
+// path or a range over a path.
+type jsonPathNode struct {
+	text   string
+	path   []jsonPathStep
+	isPath bool
+	// rangeBody is set for range nodes, path contains the ranged path
+	rangeBody []jsonPathNode
+	isRange   bool
+}
+
+// jsonPathStep is a single step of a path: a field selection, an index
+// or a wildcard.
+type jsonPathStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// ParseJSONPath parses a JSONPath template.
+func ParseJSONPath(template string) (*JSONPath, error) {
+	p := &jsonPathParser{input: template}
+	nodes, err := p.parseNodes(false)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPath{nodes: nodes}, nil
+}
+
+type jsonPathParser struct {
+	input string
+	pos   int
+}
+
+func (p *jsonPathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid jsonpath template %q at position %d: %s", p.input, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// parseNodes parses nodes until the end of the template, or until an
+// {end} action when inRange is true.
+func (p *jsonPathParser) parseNodes(inRange bool) ([]jsonPathNode, error) {
+	var nodes []jsonPathNode
+	for p.pos < len(p.input) {
+		open := strings.IndexByte(p.input[p.pos:], '{')
+		if open < 0 {
+			nodes = append(nodes, jsonPathNode{text: p.input[p.pos:]})
+			p.pos = len(p.input)
+			break
+		}
+		if open > 0 {
+			nodes = append(nodes, jsonPathNode{text: p.input[p.pos : p.pos+open]})
+			p.pos += open
+		}
+
+		actionStart := p.pos
+		action, err := p.readAction()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case action == "end":
+			if !inRange {
+				p.pos = actionStart
+				return nil, p.errorf("unexpected {end}")
+			}
+			return nodes, nil
+		case strings.HasPrefix(action, "range "):
+			path, err := p.parsePath(strings.TrimSpace(strings.TrimPrefix(action, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			body, err := p.parseNodes(true)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, jsonPathNode{path: path, rangeBody: body, isRange: true})
+		case strings.HasPrefix(action, `"`):
+			text, err := strconv.Unquote(action)
+			if err != nil {
+				p.pos = actionStart
+				return nil, p.errorf("invalid string literal %s", action)
+			}
+			nodes = append(nodes, jsonPathNode{text: text})
+		default:
+			path, err := p.parsePath(action)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, jsonPathNode{path: path, isPath: true})
+		}
+	}
+	if inRange {
+		return nil, p.errorf("missing {end}")
+	}
+	return nodes, nil
+}
+
+// readAction reads an action enclosed in braces, taking care of braces
+// inside string literals.
+func (p *jsonPathParser) readAction() (string, error) {
+	start := p.pos
+	inString := false
+	for i := p.pos + 1; i < len(p.input); i++ {
+		switch c := p.input[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && c == '}':
+			p.pos = i + 1
+			return strings.TrimSpace(p.input[start+1 : i]), nil
+		}
+	}
+	return "", p.errorf("unclosed action")
+}
+
+// parsePath parses a path expression such as .items[*].name
+func (p *jsonPathParser) parsePath(expr string) ([]jsonPathStep, error) {
+	expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), "@")
+	var steps []jsonPathStep
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				i++
+			}
+			field := expr[start:i]
+			if field == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else if field != "" {
+				steps = append(steps, jsonPathStep{field: field})
+			}
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, p.errorf("unclosed bracket in %q", expr)
+			}
+			selector := strings.TrimSpace(expr[i+1 : i+end])
+			i += end + 1
+			switch {
+			case selector == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				steps = append(steps, jsonPathStep{field: selector[1 : len(selector)-1]})
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, p.errorf("invalid array index %q", selector)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, p.errorf("unexpected %q in path %q", expr[i], expr)
+		}
+	}
+	return steps, nil
+}
+
+// Execute applies the template to the JSON representation of data and
+// writes the output to w.
+func (j *JSONPath) Execute(w io.Writer, data interface{}) error {
+	generic, err := toGeneric(data)
+	if err != nil {
+		return err
+	}
+	return executeJSONPathNodes(w, j.nodes, generic)
+}
+
+func executeJSONPathNodes(w io.Writer, nodes []jsonPathNode, current interface{}) error {
+	for _, node := range nodes {
+		if !node.isPath && !node.isRange {
+			if _, err := io.WriteString(w, node.text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		results := evalJSONPath(node.path, current)
+		if node.isRange {
+			// ranging over a single array iterates over its elements
+			if len(results) == 1 {
+				if items, ok := results[0].([]interface{}); ok {
+					results = items
+				}
+			}
+			for _, result := range results {
+				if err := executeJSONPathNodes(w, node.rangeBody, result); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		formatted := make([]string, 0, len(results))
+		for _, result := range results {
+			s, err := formatJSONPathValue(result)
+			if err != nil {
+				return err
+			}
+			formatted = append(formatted, s)
+		}
+		if _, err := io.WriteString(w, strings.Join(formatted, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evalJSONPath returns the values selected by a path. Missing fields and
+// out of range indexes are ignored.
+func evalJSONPath(steps []jsonPathStep, current interface{}) []interface{} {
+	values := []interface{}{current}
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if field, ok := v[step.field]; ok && !step.isIndex {
+					next = append(next, field)
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, v...)
+				} else if step.isIndex {
+					index := step.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// formatJSONPathValue formats a value selected by a path. Strings are
+// printed as is, other values are printed using their JSON representation.
+func formatJSONPathValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// toGeneric converts data to its generic JSON representation, made of
+// maps, slices, strings, json.Number, booleans and nil values.
+func toGeneric(data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
@@ -0,0 +1,87 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONPath_Execute(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []map[string]interface{}{
+			{"name": "runtime", "type": "core", "status": map[string]interface{}{"dirty": true, "ahead": 2}},
+			{"name": "s3-controller", "type": "controller", "labels": []string{"a", "b"}},
+		},
+		"rootDirectory": "/src",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"literal text", "hello", "hello"},
+		{"root field", "{.rootDirectory}", "/src"},
+		{"root field with dollar", "{$.rootDirectory}", "/src"},
+		{"index", "{.items[0].name}", "runtime"},
+		{"negative index", "{.items[-1].name}", "s3-controller"},
+		{"out of range index", "{.items[5].name}", ""},
+		{"quoted field", "{.items[0]['name']}", "runtime"},
+		{"wildcard", "{.items[*].name}", "runtime s3-controller"},
+		{"missing fields are skipped", "{.items[*].status.dirty}", "true"},
+		{"number", "{.items[0].status.ahead}", "2"},
+		{"map value", "{.items[0].status}", `{"ahead":2,"dirty":true}`},
+		{"array value", "{.items[1].labels}", `["a","b"]`},
+		{"map wildcard", "{.items[0].status.*}", "2 true"},
+		{"text around actions", "dir={.rootDirectory}!", "dir=/src!"},
+		{"string literal", `{"\n"}{"{}"}`, "\n{}"},
+		{"range", `{range .items[*]}{.name}:{.type}{"\n"}{end}`, "runtime:core\ns3-controller:controller\n"},
+		{"range over array", `{range .items}[{.name}]{end}`, "[runtime][s3-controller]"},
+		{"nested range", `{range .items[*]}{range .labels[*]}{.}{end}{end}`, "ab"},
+		{"current", "{.items[1].labels[0]}{@}", `a{"items":[{"name":"runtime","status":{"ahead":2,"dirty":true},"type":"core"},{"labels":["a","b"],"name":"s3-controller","type":"controller"}],"rootDirectory":"/src"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := ParseJSONPath(tt.template)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, j.Execute(&buf, data))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestParseJSONPath_errors(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{"{.name", `invalid jsonpath template "{.name" at position 1: unclosed action`},
+		{"{end}", `invalid jsonpath template "{end}" at position 1: unexpected {end}`},
+		{"{range .items[*]}{.name}", `invalid jsonpath template "{range .items[*]}{.name}" at position 25: missing {end}`},
+		{"{.items[a]}", `invalid jsonpath template "{.items[a]}" at position 12: invalid array index "a"`},
+		{"{.items[0}", `invalid jsonpath template "{.items[0}" at position 11: unclosed bracket in ".items[0"`},
+		{`{"\q"}`, `invalid jsonpath template "{\"\\q\"}" at position 1: invalid string literal "\q"`},
+		{"{name}", `invalid jsonpath template "{name}" at position 7: unexpected 'n' in path "name"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseJSONPath(tt.template)
+		assert.EqualError(t, err, tt.wantErr, tt.template)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package printer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Format is an output format supported by Printer.
+type Format string
+
+const (
+	// FormatTable prints objects in a kubectl like table.
+	FormatTable Format = "table"
+	// FormatWide prints objects in a table with additional columns.
+	FormatWide Format = "wide"
+	// FormatJSON prints objects as indented JSON.
+	FormatJSON Format = "json"
+	// FormatYAML prints objects as YAML.
+	FormatYAML Format = "yaml"
+	// FormatName prints the object names, one per line.
+	FormatName Format = "name"
+	// FormatJSONPath prints the result of a JSONPath template applied to
+	// the JSON representation of objects.
+	FormatJSONPath Format = "jsonpath"
+	// FormatGoTemplate prints the result of a go template applied to the
+	// JSON representation of objects.
+	FormatGoTemplate Format = "go-template"
+)
+
+// SupportedFormats is the list of formats accepted by New, suitable to
+// be displayed in flags usage.
+const SupportedFormats = "table|wide|json|yaml|name|jsonpath=...|go-template=..."
+
+var (
+	// ErrUnsupportedFormat is returned when an output format is unknown
+	// or cannot be used to print an object.
+	ErrUnsupportedFormat = errors.New("unsupported output format")
+)
+
+// Tabular is implemented by objects that can be printed as a table.
+type Tabular interface {
+	// Table returns the table header and rows. When wide is true,
+	// additional columns are returned.
+	Table(wide bool) (header []string, rows [][]string)
+}
+
+// Named is implemented by objects that can be printed using the name
+// format.
+type Named interface {
+	// Names returns the names of the objects.
+	Names() []string
+}
+
+// Printer prints objects in one of the supported formats.
+type Printer struct {
+	format   Format
+	jsonPath *JSONPath
+	template *template.Template
+}
+
+// New parses an output format specification, such as "json" or
+// "jsonpath={.items[*].name}", and returns a Printer for it.
+func New(output string) (*Printer, error) {
+	name, arg := output, ""
+	if i := strings.IndexByte(output, '='); i >= 0 {
+		name, arg = output[:i], output[i+1:]
+	}
+
+	p := &Printer{format: Format(name)}
+	switch p.format {
+	case FormatTable, FormatWide, FormatJSON, FormatYAML, FormatName:
+		if arg != "" {
+			return nil, fmt.Errorf("%w: %s does not take an argument", ErrUnsupportedFormat, name)
+		}
+	case FormatJSONPath:
+		if arg == "" {
+			return nil, fmt.Errorf("%w: missing template, expected jsonpath=<template>", ErrUnsupportedFormat)
+		}
+		jsonPath, err := ParseJSONPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		p.jsonPath = jsonPath
+	case FormatGoTemplate:
+		if arg == "" {
+			return nil, fmt.Errorf("%w: missing template, expected go-template=<template>", ErrUnsupportedFormat)
+		}
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid go template: %w", err)
+		}
+		p.template = tmpl
+	default:
+		return nil, fmt.Errorf("%w: %q, expected one of %s", ErrUnsupportedFormat, output, SupportedFormats)
+	}
+	return p, nil
+}
+
+// Format returns the printer output format.
+func (p *Printer) Format() Format {
+	return p.format
+}
+
+// Print writes obj to w in the printer format. obj must implement Tabular
+// to be printed as a table and Named to be printed using the name format.
+func (p *Printer) Print(w io.Writer, obj interface{}) error {
+	switch p.format {
+	case FormatTable, FormatWide:
+		t, ok := obj.(Tabular)
+		if !ok {
+			return fmt.Errorf("%w: %s is not available for this resource", ErrUnsupportedFormat, p.format)
+		}
+		header, rows := t.Table(p.format == FormatWide)
+		tw := NewTable(w)
+		tw.SetHeader(header)
+		tw.AppendBulk(rows)
+		tw.Render()
+		return nil
+	case FormatName:
+		n, ok := obj.(Named)
+		if !ok {
+			return fmt.Errorf("%w: %s is not available for this resource", ErrUnsupportedFormat, p.format)
+		}
+		for _, name := range n.Names() {
+			if _, err := fmt.Fprintln(w, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(obj)
+	case FormatYAML:
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case FormatJSONPath:
+		return p.jsonPath.Execute(w, obj)
+	case FormatGoTemplate:
+		generic, err := toGeneric(obj)
+		if err != nil {
+			return err
+		}
+		return p.template.Execute(w, generic)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, p.format)
+}
+
+// NewTable returns a table writer using a kubectl like style.
+func NewTable(w io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+
+	// Kubectl tables like style
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding(" ")
+	table.SetNoWhiteSpace(true)
+	return table
+}
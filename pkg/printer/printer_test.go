@@ -0,0 +1,114 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+type testList struct {
+	Items []testItem `json:"items"`
+}
+
+func (l *testList) Table(wide bool) ([]string, [][]string) {
+	header := []string{"Name"}
+	if wide {
+		header = append(header, "Version")
+	}
+	rows := [][]string{}
+	for _, item := range l.Items {
+		row := []string{item.Name}
+		if wide {
+			row = append(row, "v1")
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+func (l *testList) Names() []string {
+	names := []string{}
+	for _, item := range l.Items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestPrinter_Print(t *testing.T) {
+	list := &testList{Items: []testItem{{"kind", 1}, {"helm", 3}}}
+
+	tests := []struct {
+		name   string
+		output string
+		obj    interface{}
+		want   string
+	}{
+		{"table", "table", list, "NAME \nkind \nhelm \n"},
+		{"wide", "wide", list, "NAME VERSION \nkind v1      \nhelm v1      \n"},
+		{"name", "name", list, "kind\nhelm\n"},
+		{"json", "json", testItem{"kind", 1}, "{\n    \"name\": \"kind\",\n    \"version\": 1\n}\n"},
+		{"yaml", "yaml", list, "items:\n- name: kind\n  version: 1\n- name: helm\n  version: 3\n"},
+		{"jsonpath", "jsonpath={.items[*].name}", list, "kind helm"},
+		{"jsonpath with equal sign", `jsonpath={range .items[*]}{.name}={.version}{"\n"}{end}`, list, "kind=1\nhelm=3\n"},
+		{"go-template", `go-template={{range .items}}{{.name}} {{.version}};{{end}}`, list, "kind 1;helm 3;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.output)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, p.Print(&buf, tt.obj))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestPrinter_Print_unsupported(t *testing.T) {
+	for _, output := range []string{"table", "wide", "name"} {
+		p, err := New(output)
+		require.NoError(t, err)
+
+		err = p.Print(&bytes.Buffer{}, testItem{"kind", 1})
+		assert.True(t, errors.Is(err, ErrUnsupportedFormat), output)
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	tests := []struct {
+		output  string
+		wantErr string
+	}{
+		{"xml", `unsupported output format: "xml", expected one of ` + SupportedFormats},
+		{"json=x", "unsupported output format: json does not take an argument"},
+		{"jsonpath", "unsupported output format: missing template, expected jsonpath=<template>"},
+		{"go-template=", "unsupported output format: missing template, expected go-template=<template>"},
+		{"jsonpath={.items", `invalid jsonpath template "{.items" at position 1: unclosed action`},
+		{"go-template={{.name", "invalid go template: template: output:1: unclosed action"},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.output)
+		assert.EqualError(t, err, tt.wantErr, tt.output)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnsureAction describes an operation performed while ensuring a repository.
+type EnsureAction string
+
+const (
+	// EnsureActionForked is recorded when a new fork was created.
+	EnsureActionForked EnsureAction = "forked"
+	// EnsureActionRenamed is recorded when a fork was renamed to its expected name.
+	EnsureActionRenamed EnsureAction = "renamed"
+	// EnsureActionCloned is recorded when a fork was cloned locally.
+	EnsureActionCloned EnsureAction = "cloned"
+)
+
+// EnsureResult holds the outcome of ensuring a single repository.
+type EnsureResult struct {
+	// Repository is the ensured repository
+	Repository *Repository
+	// Actions is the list of actions taken, in order.
+	Actions []EnsureAction
+	// Err is the error that interrupted the ensure operation, if any.
+	Err error
+}
+
+// Status returns a short human readable summary of the result. It returns
+// "failed" if an error occurred, "skipped" if no action was needed and a
+// comma separated list of the actions taken otherwise.
+func (r *EnsureResult) Status() string {
+	if r.Err != nil {
+		return "failed"
+	}
+	if len(r.Actions) == 0 {
+		return "skipped"
+	}
+	actions := make([]string, 0, len(r.Actions))
+	for _, action := range r.Actions {
+		actions = append(actions, string(action))
+	}
+	return strings.Join(actions, ",")
+}
+
+// EnsureError aggregates the errors of all the repositories that failed
+// to be ensured.
+type EnsureError struct {
+	// Failures is the list of failed results
+	Failures []*EnsureResult
+}
+
+// NewEnsureError returns an *EnsureError containing the failed results, or
+// nil if none of the results failed.
+func NewEnsureError(results []*EnsureResult) error {
+	var failures []*EnsureResult
+	for _, res := range results {
+		if res.Err != nil {
+			failures = append(failures, res)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &EnsureError{Failures: failures}
+}
+
+// Error implements the error interface. It lists every failed repository
+// along with the reason of the failure.
+func (e *EnsureError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "failed to ensure %d repositories:", len(e.Failures))
+	for _, res := range e.Failures {
+		fmt.Fprintf(&sb, "\n  %s: %v", res.Repository.Name, res.Err)
+	}
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureResult_Status(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *EnsureResult
+		want   string
+	}{
+		{
+			name:   "no actions",
+			result: &EnsureResult{},
+			want:   "skipped",
+		},
+		{
+			name: "forked renamed and cloned",
+			result: &EnsureResult{
+				Actions: []EnsureAction{EnsureActionForked, EnsureActionRenamed, EnsureActionCloned},
+			},
+			want: "forked,renamed,cloned",
+		},
+		{
+			name: "failed after fork",
+			result: &EnsureResult{
+				Actions: []EnsureAction{EnsureActionForked},
+				Err:     errors.New("rename failed"),
+			},
+			want: "failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.result.Status())
+		})
+	}
+}
+
+func TestNewEnsureError(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(NewEnsureError(nil))
+	assert.NoError(NewEnsureError([]*EnsureResult{{Repository: &Repository{Name: "runtime"}}}))
+
+	err := NewEnsureError([]*EnsureResult{
+		{Repository: &Repository{Name: "runtime"}},
+		{Repository: &Repository{Name: "s3-controller"}, Err: errors.New("boom")},
+	})
+	assert.EqualError(err, "failed to ensure 1 repositories:\n  s3-controller: boom")
+}
@@ -16,6 +16,9 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -24,36 +27,569 @@ var (
 	ErrUnknownFilterKey            error = errors.New("unknown filter key")
 )
 
-// BuildFilters takes an expression string and returns a list
-// of Filter functions. Example: "branch=main type=controller"
+// BuildFilters takes an expression string and returns a list of Filter
+// functions, one per top level AND term. A repository matches the expression
+// if it matches all the returned filters. See ParseFilterExpression for the
+// expression syntax. Example: "branch=main type=controller"
 func BuildFilters(expression string) ([]Filter, error) {
-	expression = strings.TrimSpace(expression)
-	if len(expression) == 0 {
-		return []Filter{NoFilter}, nil
+	expr, err := ParseFilterExpression(expression)
+	if err != nil {
+		return nil, err
 	}
+	return expr.Filters(), nil
+}
+
+// FilterExpression is a parsed filter expression.
+type FilterExpression struct {
+	// terms are the top level terms, joined with AND
+	terms []Filter
+	// needsStatus is true if the expression uses keys computed from the
+	// repositories Status
+	needsStatus bool
+}
+
+// Filters returns the top level terms of the expression. A repository
+// matches the expression if it matches all the filters.
+func (e *FilterExpression) Filters() []Filter {
+	return e.terms
+}
+
+// NeedsStatus returns true if the expression uses keys (dirty, ahead,
+// behind, untracked) that can only be evaluated once the repositories
+// Status is loaded.
+func (e *FilterExpression) NeedsStatus() bool {
+	return e.needsStatus
+}
+
+// FilterSyntaxError is returned when a filter expression is malformed. It
+// wraps ErrMalformatedFilterExpression or ErrUnknownFilterKey.
+type FilterSyntaxError struct {
+	// Expression is the malformed expression
+	Expression string
+	// Position is the byte offset of the error in the expression
+	Position int
+	// Message describes the error
+	Message string
+
+	err error
+}
+
+// Error implements the error interface. The message points at the error
+// position in the expression.
+func (e *FilterSyntaxError) Error() string {
+	return fmt.Sprintf(
+		"%v at position %d: %s\n  %s\n  %s^",
+		e.err, e.Position+1, e.Message, e.Expression, strings.Repeat(" ", e.Position),
+	)
+}
+
+// Unwrap returns ErrMalformatedFilterExpression or ErrUnknownFilterKey.
+func (e *FilterSyntaxError) Unwrap() error {
+	return e.err
+}
+
+// ParseFilterExpression parses a filter expression. An expression is a list
+// of comparisons joined with AND (or a space), OR and NOT operators, grouped
+// with parentheses. AND has precedence over OR.
+//
+// A comparison has the form key<operator>value. Values containing spaces or
+// parentheses can be quoted with " or '. Supported operators are:
+//   - '=' or '==': equality, values containing '*', '?' or '[' are globs
+//   - '!=': inequality or glob mismatch
+//   - '~=': regular expression match
+//   - '!~': regular expression mismatch
+//   - '<', '<=', '>', '>=': numeric comparisons
+//
+// Supported keys are name, type, branch, state (strings), cloned, forked,
+// dirty (booleans), ahead, behind and untracked (integers).
+//
+// Example: "type=controller AND (name~=^s3 OR branch!=main) dirty=false"
+func ParseFilterExpression(expression string) (*FilterExpression, error) {
+	p := &filterParser{input: expression}
+	expr := &FilterExpression{}
+
+	p.skipSpaces()
+	if p.eof() {
+		expr.terms = []Filter{NoFilter}
+		return expr, nil
+	}
+
+	groups, err := p.parseOrGroups()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", p.input[p.pos])
+	}
+
+	if len(groups) == 1 {
+		expr.terms = groups[0]
+	} else {
+		expr.terms = []Filter{orFilter(groups)}
+	}
+	expr.needsStatus = p.needsStatus
+	return expr, nil
+}
+
+// filterParser is a recursive descent parser for filter expressions. It
+// implements the following grammar:
+//
+//	or         := and ( ("OR" | "||") and )*
+//	and        := unary ( ["AND" | "&&"] unary )*
+//	unary      := ("NOT" | "!") unary | primary
+//	primary    := "(" or ")" | comparison
+//	comparison := key operator value
+type filterParser struct {
+	input       string
+	pos         int
+	needsStatus bool
+}
+
+func (p *filterParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *filterParser) skipSpaces() {
+	for !p.eof() && isFilterSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
 
-	var filters []Filter
-	expressionElements := strings.Split(expression, " ")
-	for _, filterStr := range expressionElements {
-		// TODO(hilalymh) probably we can use a regular expression instead..
-		filterArgs := strings.Split(filterStr, "=")
-		if len(filterArgs) != 2 {
-			return nil, ErrMalformatedFilterExpression
+// errorf returns a FilterSyntaxError positioned at pos.
+func (p *filterParser) errorf(pos int, format string, args ...interface{}) error {
+	return &FilterSyntaxError{
+		Expression: p.input,
+		Position:   pos,
+		Message:    fmt.Sprintf(format, args...),
+		err:        ErrMalformatedFilterExpression,
+	}
+}
+
+// consumeKeyword consumes one of the given keywords (case insensitive for
+// words) if it is the next token.
+func (p *filterParser) consumeKeyword(keywords ...string) bool {
+	for _, keyword := range keywords {
+		end := p.pos + len(keyword)
+		if end > len(p.input) || !strings.EqualFold(p.input[p.pos:end], keyword) {
+			continue
+		}
+		// words must be followed by a delimiter
+		if isFilterWordChar(keyword[0]) && end < len(p.input) && isFilterWordChar(p.input[end]) {
+			continue
+		}
+		// '!' is also the first character of '!=' and '!~'
+		if keyword == "!" && end < len(p.input) && (p.input[end] == '=' || p.input[end] == '~') {
+			continue
+		}
+		p.pos = end
+		return true
+	}
+	return false
+}
+
+// parseOrGroups parses OR separated groups of AND terms.
+func (p *filterParser) parseOrGroups() ([][]Filter, error) {
+	var groups [][]Filter
+	for {
+		terms, err := p.parseAndTerms()
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, terms)
+
+		p.skipSpaces()
+		if !p.consumeKeyword("OR", "||") {
+			return groups, nil
+		}
+		p.skipSpaces()
+	}
+}
+
+// parseAndTerms parses AND separated (or space separated) terms.
+func (p *filterParser) parseAndTerms() ([]Filter, error) {
+	var terms []Filter
+	for {
+		p.skipSpaces()
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+
+		p.skipSpaces()
+		if p.eof() || p.input[p.pos] == ')' {
+			return terms, nil
 		}
-		key := strings.ToLower(filterArgs[0])
-		value := filterArgs[1]
-		switch key {
-		case "type":
-			filters = append(filters, TypeFilter(value))
-		case "name":
-			filters = append(filters, NameFilter(value))
-		case "branch":
-			filters = append(filters, BranchFilter(value))
-		default:
-			return nil, fmt.Errorf("unknown filter key: %s", key)
+		start := p.pos
+		if p.consumeKeyword("OR", "||") {
+			p.pos = start
+			return terms, nil
 		}
+		if p.consumeKeyword("AND", "&&") {
+			p.skipSpaces()
+			if p.eof() {
+				return nil, p.errorf(p.pos, "expected expression after AND")
+			}
+		}
+	}
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.consumeKeyword("NOT", "!") {
+		p.skipSpaces()
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter(f), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (Filter, error) {
+	if p.eof() {
+		return nil, p.errorf(p.pos, "expected expression")
+	}
+	if p.input[p.pos] == '(' {
+		open := p.pos
+		p.pos++
+		p.skipSpaces()
+		groups, err := p.parseOrGroups()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.eof() || p.input[p.pos] != ')' {
+			return nil, p.errorf(open, "unclosed parenthesis")
+		}
+		p.pos++
+		return orFilter(groups), nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (Filter, error) {
+	keyPos := p.pos
+	for !p.eof() && isFilterWordChar(p.input[p.pos]) {
+		p.pos++
+	}
+	key := strings.ToLower(p.input[keyPos:p.pos])
+	if key == "" {
+		return nil, p.errorf(keyPos, "expected filter key, got %q", p.input[keyPos])
+	}
+	if keyword := strings.ToUpper(key); keyword == "AND" || keyword == "OR" {
+		return nil, p.errorf(keyPos, "unexpected %s", keyword)
+	}
+
+	opPos := p.pos
+	op := ""
+	for _, candidate := range filterOperators {
+		if strings.HasPrefix(p.input[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf(opPos, "expected operator after key %q", key)
+	}
+	p.pos += len(op)
+
+	valuePos := p.pos
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	fk, ok := filterKeys[key]
+	if !ok {
+		return nil, &FilterSyntaxError{
+			Expression: p.input,
+			Position:   keyPos,
+			Message:    fmt.Sprintf("unknown filter key %q", key),
+			err:        ErrUnknownFilterKey,
+		}
+	}
+	if fk.needsStatus {
+		p.needsStatus = true
+	}
+
+	f, err := fk.compile(op, value)
+	if err != nil {
+		return nil, p.errorf(valuePos, "%v", err)
+	}
+	return f, nil
+}
+
+// parseValue parses a quoted or a bare value. Bare values end with a space
+// or a closing parenthesis that doesn't match an opening parenthesis of the
+// value itself.
+func (p *filterParser) parseValue() (string, error) {
+	start := p.pos
+	if !p.eof() && (p.input[p.pos] == '"' || p.input[p.pos] == '\'') {
+		quote := p.input[p.pos]
+		end := strings.IndexByte(p.input[p.pos+1:], quote)
+		if end < 0 {
+			return "", p.errorf(start, "unterminated quoted value")
+		}
+		p.pos += end + 2
+		return p.input[start+1 : p.pos-1], nil
+	}
+
+	depth := 0
+	for !p.eof() && !isFilterSpace(p.input[p.pos]) {
+		c := p.input[p.pos]
+		if c == '(' {
+			depth++
+		} else if c == ')' {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf(start, "expected value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+func isFilterSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isFilterWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// filterOperators is the list of supported operators. Longer operators must
+// come first.
+var filterOperators = []string{"==", "!=", "~=", "!~", "<=", ">=", "=", "<", ">"}
+
+type filterValueKind int
+
+const (
+	filterValueString filterValueKind = iota
+	filterValueBool
+	filterValueInt
+)
+
+// filterKey describes a key usable in filter expressions.
+type filterKey struct {
+	kind filterValueKind
+	// needsStatus is true if the key is computed from Repository.Status
+	needsStatus bool
+	// validate optionally validates the values of exact comparisons
+	validate func(value string) error
+
+	getString func(r *Repository) string
+	getBool   func(r *Repository) bool
+	// getInt returns false if the value is unknown
+	getInt func(r *Repository) (int, bool)
+}
+
+var filterKeys = map[string]*filterKey{
+	"name": {
+		kind:      filterValueString,
+		getString: func(r *Repository) string { return r.Name },
+	},
+	"type": {
+		kind: filterValueString,
+		validate: func(value string) error {
+			_, err := repositoryTypeFromString(value)
+			return err
+		},
+		getString: func(r *Repository) string { return r.Type.String() },
+	},
+	"branch": {
+		kind:      filterValueString,
+		getString: func(r *Repository) string { return r.GitHead },
+	},
+	"state": {
+		kind: filterValueString,
+		validate: func(value string) error {
+			_, err := repositoryStateFromString(value)
+			return err
+		},
+		getString: func(r *Repository) string { return r.State.String() },
+	},
+	"cloned": {
+		kind:    filterValueBool,
+		getBool: func(r *Repository) bool { return r.State != RepositoryStateNotCloned },
+	},
+	"forked": {
+		kind:    filterValueBool,
+		getBool: func(r *Repository) bool { return r.Forked },
+	},
+	"dirty": {
+		kind:        filterValueBool,
+		needsStatus: true,
+		getBool:     func(r *Repository) bool { return r.Status != nil && r.Status.Dirty },
+	},
+	"ahead": {
+		kind:        filterValueInt,
+		needsStatus: true,
+		getInt: func(r *Repository) (int, bool) {
+			if r.Status == nil || r.Status.Upstream == nil {
+				return 0, false
+			}
+			return r.Status.Upstream.Ahead, true
+		},
+	},
+	"behind": {
+		kind:        filterValueInt,
+		needsStatus: true,
+		getInt: func(r *Repository) (int, bool) {
+			if r.Status == nil || r.Status.Upstream == nil {
+				return 0, false
+			}
+			return r.Status.Upstream.Behind, true
+		},
+	},
+	"untracked": {
+		kind:        filterValueInt,
+		needsStatus: true,
+		getInt: func(r *Repository) (int, bool) {
+			if r.Status == nil {
+				return 0, false
+			}
+			return r.Status.UntrackedFiles, true
+		},
+	},
+}
+
+// compile returns a Filter comparing the key with the given value.
+func (fk *filterKey) compile(op, value string) (Filter, error) {
+	switch fk.kind {
+	case filterValueString:
+		return fk.compileString(op, value)
+	case filterValueBool:
+		return fk.compileBool(op, value)
+	default:
+		return fk.compileInt(op, value)
+	}
+}
+
+func (fk *filterKey) compileString(op, value string) (Filter, error) {
+	var match func(s string) bool
+	switch op {
+	case "=", "==", "!=":
+		if strings.ContainsAny(value, "*?[") {
+			if _, err := path.Match(value, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob pattern %q", value)
+			}
+			match = func(s string) bool {
+				ok, _ := path.Match(value, s)
+				return ok
+			}
+		} else {
+			if fk.validate != nil {
+				if err := fk.validate(value); err != nil {
+					return nil, err
+				}
+			}
+			match = func(s string) bool { return s == value }
+		}
+	case "~=", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", value, err)
+		}
+		match = re.MatchString
+	default:
+		return nil, fmt.Errorf("operator %s is not supported for string keys", op)
+	}
+
+	negate := op == "!=" || op == "!~"
+	return func(r *Repository) bool {
+		return match(fk.getString(r)) != negate
+	}, nil
+}
+
+func (fk *filterKey) compileBool(op, value string) (Filter, error) {
+	var want bool
+	switch strings.ToLower(value) {
+	case "true", "yes", "1":
+		want = true
+	case "false", "no", "0":
+		want = false
+	default:
+		return nil, fmt.Errorf("invalid boolean value %q", value)
+	}
+	switch op {
+	case "=", "==":
+	case "!=":
+		want = !want
+	default:
+		return nil, fmt.Errorf("operator %s is not supported for boolean keys", op)
+	}
+	return func(r *Repository) bool {
+		return fk.getBool(r) == want
+	}, nil
+}
+
+func (fk *filterKey) compileInt(op, value string) (Filter, error) {
+	want, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer value %q", value)
+	}
+
+	var compare func(v int) bool
+	switch op {
+	case "=", "==":
+		compare = func(v int) bool { return v == want }
+	case "!=":
+		compare = func(v int) bool { return v != want }
+	case "<":
+		compare = func(v int) bool { return v < want }
+	case "<=":
+		compare = func(v int) bool { return v <= want }
+	case ">":
+		compare = func(v int) bool { return v > want }
+	case ">=":
+		compare = func(v int) bool { return v >= want }
+	default:
+		return nil, fmt.Errorf("operator %s is not supported for integer keys", op)
+	}
+	return func(r *Repository) bool {
+		// repositories with unknown values never match
+		v, ok := fk.getInt(r)
+		return ok && compare(v)
+	}, nil
+}
+
+// andFilter returns a Filter matching repositories that match all the
+// given filters.
+func andFilter(filters []Filter) Filter {
+	return func(r *Repository) bool {
+		for _, filter := range filters {
+			if !filter(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// orFilter returns a Filter matching repositories that match all the
+// filters of at least one of the given groups.
+func orFilter(groups [][]Filter) Filter {
+	return func(r *Repository) bool {
+		for _, group := range groups {
+			if andFilter(group)(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// notFilter returns a Filter matching repositories that don't match
+// the given filter.
+func notFilter(filter Filter) Filter {
+	return func(r *Repository) bool {
+		return !filter(r)
 	}
-	return filters, nil
 }
 
 // A Filter is a prototype for a function that can be used to filter the
@@ -81,8 +617,9 @@ func NamePrefixFilter(namePrefix string) Filter {
 // TypeFilter filters a repository by a name prefix
 // The only two possible types are 'controller' and 'core'
 func TypeFilter(t string) Filter {
+	repoType, err := repositoryTypeFromString(t)
 	return func(r *Repository) bool {
-		return r.Type == repositoryTypeFromString(t)
+		return err == nil && r.Type == repoType
 	}
 }
 
@@ -14,10 +14,12 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBuildFilters(t *testing.T) {
@@ -140,3 +142,144 @@ func TestBranchFilter(t *testing.T) {
 	assert.True(t, branchFilter(runtimeRepo))
 	assert.False(t, branchFilter(sqsRepo))
 }
+
+func TestParseFilterExpression(t *testing.T) {
+	repos := []*Repository{
+		{
+			Name:    "runtime",
+			Type:    RepositoryTypeCore,
+			State:   RepositoryStateBranch,
+			GitHead: "main",
+			Forked:  true,
+			Status:  &Status{Upstream: &Divergence{Ahead: 0, Behind: 3}},
+		},
+		{
+			Name:    "s3-controller",
+			Type:    RepositoryTypeController,
+			State:   RepositoryStateBranch,
+			GitHead: "feature-xyz",
+			Forked:  true,
+			Status:  &Status{Dirty: true, UntrackedFiles: 2, Upstream: &Divergence{Ahead: 4, Behind: 1}},
+		},
+		{
+			Name:    "sns-controller",
+			Type:    RepositoryTypeController,
+			State:   RepositoryStateDetached,
+			GitHead: "a1b2c3d",
+			Status:  &Status{},
+		},
+		{
+			Name: "ecr-controller",
+			Type: RepositoryTypeController,
+		},
+	}
+
+	tests := []struct {
+		expression      string
+		wantMatches     []string
+		wantNeedsStatus bool
+	}{
+		{"", []string{"runtime", "s3-controller", "sns-controller", "ecr-controller"}, false},
+		{"type=controller", []string{"s3-controller", "sns-controller", "ecr-controller"}, false},
+		{"type!=controller", []string{"runtime"}, false},
+		{"name~=^s", []string{"s3-controller", "sns-controller"}, false},
+		{"name!~controller$", []string{"runtime"}, false},
+		{"name=s*-controller", []string{"s3-controller", "sns-controller"}, false},
+		{"name!=s*", []string{"runtime", "ecr-controller"}, false},
+		{"name~=^(s3|ecr)-", []string{"s3-controller", "ecr-controller"}, false},
+		{"(name~=^(s3|ecr)-)", []string{"s3-controller", "ecr-controller"}, false},
+		{"name='runtime' OR name=\"ecr-controller\"", []string{"runtime", "ecr-controller"}, false},
+		{"type=controller AND branch!=main", []string{"s3-controller", "sns-controller", "ecr-controller"}, false},
+		{"type=core OR type=controller cloned=true", []string{"runtime", "s3-controller", "sns-controller"}, false},
+		{"(type=core OR type=controller) AND cloned=false", []string{"ecr-controller"}, false},
+		{"NOT cloned=true", []string{"ecr-controller"}, false},
+		{"!(forked=yes || state=detached)", []string{"ecr-controller"}, false},
+		{"state=detached", []string{"sns-controller"}, false},
+		{"dirty=true", []string{"s3-controller"}, true},
+		{"dirty=false cloned=true", []string{"runtime", "sns-controller"}, true},
+		{"ahead>0", []string{"s3-controller"}, true},
+		{"ahead=0 behind>=3", []string{"runtime"}, true},
+		{"untracked!=0 && name~=s3", []string{"s3-controller"}, true},
+		{"  name=runtime  or  name=s3-controller  ", []string{"runtime", "s3-controller"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := ParseFilterExpression(tt.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNeedsStatus, expr.NeedsStatus())
+
+			var matches []string
+		mainLoop:
+			for _, repo := range repos {
+				for _, filter := range expr.Filters() {
+					if !filter(repo) {
+						continue mainLoop
+					}
+				}
+				matches = append(matches, repo.Name)
+			}
+			assert.Equal(t, tt.wantMatches, matches)
+		})
+	}
+}
+
+func TestParseFilterExpression_errors(t *testing.T) {
+	tests := []struct {
+		expression   string
+		wantPosition int
+		wantErr      error
+	}{
+		{"name", 4, ErrMalformatedFilterExpression},
+		{"name=", 5, ErrMalformatedFilterExpression},
+		{"=runtime", 0, ErrMalformatedFilterExpression},
+		{"name=runtime somekey=value", 13, ErrUnknownFilterKey},
+		{"type=unknown", 5, ErrMalformatedFilterExpression},
+		{"state=happy", 6, ErrMalformatedFilterExpression},
+		{"name~=^(s3", 6, ErrMalformatedFilterExpression},
+		{"name=[", 5, ErrMalformatedFilterExpression},
+		{"dirty=maybe", 6, ErrMalformatedFilterExpression},
+		{"dirty>true", 6, ErrMalformatedFilterExpression},
+		{"ahead=many", 6, ErrMalformatedFilterExpression},
+		{"name>runtime", 5, ErrMalformatedFilterExpression},
+		{"(name=runtime", 0, ErrMalformatedFilterExpression},
+		{"name=runtime)", 12, ErrMalformatedFilterExpression},
+		{"name=runtime OR", 15, ErrMalformatedFilterExpression},
+		{"name=runtime AND", 16, ErrMalformatedFilterExpression},
+		{"AND name=runtime", 0, ErrMalformatedFilterExpression},
+		{"name='runtime", 5, ErrMalformatedFilterExpression},
+		{"()", 1, ErrMalformatedFilterExpression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := ParseFilterExpression(tt.expression)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+
+			syntaxErr, ok := err.(*FilterSyntaxError)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantPosition, syntaxErr.Position, syntaxErr.Error())
+		})
+	}
+}
+
+func TestParseFilterExpression_neverPanics(t *testing.T) {
+	fragments := []string{"name", "type", "ahead", "=", "!=", "~=", "!~", ">", "(", ")", "'", "\"",
+		"AND", "OR", "NOT", "!", "&&", "||", " ", "core", "[", "*", "1", "true"}
+	// try all the combinations of 3 fragments
+	for _, a := range fragments {
+		for _, b := range fragments {
+			for _, c := range fragments {
+				expression := a + b + c
+				assert.NotPanics(t, func() {
+					_, _ = ParseFilterExpression(expression)
+				}, expression)
+			}
+		}
+	}
+}
+
+func TestTypeFilter_unknownType(t *testing.T) {
+	assert.NotPanics(t, func() {
+		assert.False(t, TypeFilter("unknown")(&Repository{Type: RepositoryTypeCore}))
+	})
+}
@@ -17,11 +17,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
-	"time"
+	"sync"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
 	git "gopkg.in/src-d/go-git.v4"
 	gitconfig "gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport"
@@ -35,6 +35,8 @@ import (
 const (
 	originRemoteName   = "origin"
 	upstreamRemoteName = "upstream"
+	// defaultBranchName is the default branch of all ACK repositories
+	defaultBranchName = "main"
 )
 
 var (
@@ -45,24 +47,69 @@ var (
 	ErrRepositoryAlreadyExist error = errors.New("repository already exist")
 )
 
+// ManagerOption is a function that configures a Manager.
+type ManagerOption func(*managerOptions)
+
+type managerOptions struct {
+	githubClient   github.RepositoryService
+	githubCacheDir string
+}
+
+// WithGithubClient sets the client used by the Manager to call the Github API.
+// By default a client authenticated with the configured token is used.
+func WithGithubClient(ghc github.RepositoryService) ManagerOption {
+	return func(o *managerOptions) {
+		o.githubClient = ghc
+	}
+}
+
+// WithGithubCacheDirectory sets the directory caching the Github API responses
+// of the default Github client. It's ignored if WithGithubClient is used.
+func WithGithubCacheDirectory(dir string) ManagerOption {
+	return func(o *managerOptions) {
+		o.githubCacheDir = dir
+	}
+}
+
 // NewManager create a new manager.
-func NewManager(cfg *config.Config) (*Manager, error) {
-	githubClient := github.NewClient(cfg.Github.Token)
+func NewManager(cfg *config.Config, opts ...ManagerOption) (*Manager, error) {
+	options := &managerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	githubClient := options.githubClient
+	if githubClient == nil {
+		ghc, err := github.NewClientFromConfig(&cfg.Github, options.githubCacheDir)
+		if err != nil {
+			return nil, err
+		}
+		githubClient = ghc
+	}
 	gitOpts := []ackdevgit.Option{
 		ackdevgit.WithRemote(originRemoteName),
 	}
-	urlBuilder := httpsRemoteURL
+	urlBuilder := httpsRemoteURLBuilder(cfg.Github.Host())
+
+	if cfg.Git.KnownHostsPath != "" {
+		gitOpts = append(gitOpts, ackdevgit.WithKnownHosts(cfg.Git.KnownHostsPath))
+	}
 
 	// Add git authentication options
-	if cfg.Git.SSHKeyPath == "" {
+	var sshSigner func() (ssh.Signer, error)
+	if cfg.Git.SSHAgent {
+		gitOpts = append(gitOpts, ackdevgit.WithSSHAgent())
+		urlBuilder = sshRemoteURLBuilder(cfg.Github.SSHHost())
+	} else if cfg.Git.SSHKeyPath == "" {
 		gitOpts = append(gitOpts,
 			ackdevgit.WithGithubCredentials(cfg.Github.Username, cfg.Github.Token),
 		)
 	} else {
-		// TODO(hilalymh) set ssh.Signer here.. figure out how to deal with encrypted
-		// keys properly...
-		gitOpts = append(gitOpts, ackdevgit.WithSSHSigner(nil))
-		urlBuilder = sshRemoteURL
+		// The signer is loaded lazily, so that commands that don't need to talk
+		// to remote repositories never prompt for the key passphrase.
+		sshSigner = util.NewSignerOnce(cfg.Git.SSHKeyPath)
+		gitOpts = append(gitOpts, ackdevgit.WithSSHSignerFunc(sshSigner))
+		urlBuilder = sshRemoteURLBuilder(cfg.Github.SSHHost())
 	}
 
 	gitClient := ackdevgit.New(gitOpts...)
@@ -73,6 +120,8 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 		cfg:        cfg,
 		ghc:        githubClient,
 		git:        gitClient,
+		sshSigner:  sshSigner,
+		sshAgent:   cfg.Git.SSHAgent,
 		urlBuilder: urlBuilder,
 	}, nil
 }
@@ -80,13 +129,50 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 // Manager is reponsible of managing local ACK local repositories and
 // github forks.
 type Manager struct {
+	// mu protects repoCache
+	mu        sync.RWMutex
 	repoCache map[string]*Repository
 
 	log        *logrus.Logger
 	cfg        *config.Config
-	git        ackdevgit.OpenCloner
+	git        ackdevgit.Client
 	ghc        github.RepositoryService
 	urlBuilder func(owner, repo string) string
+	// sshSigner is nil when repositories are cloned using HTTPS
+	sshSigner func() (ssh.Signer, error)
+	// sshAgent is true when repositories are cloned using the ssh-agent
+	sshAgent bool
+}
+
+// upstreamOrg returns the organisation owning the upstream repositories.
+func (m *Manager) upstreamOrg() string {
+	if m.cfg.Github.UpstreamOrg != "" {
+		return m.cfg.Github.UpstreamOrg
+	}
+	return github.ACKOrg
+}
+
+// upstreamOwner returns the owner of the upstream repository of repo.
+func (m *Manager) upstreamOwner(repo *Repository) string {
+	if repo.UpstreamOwner != "" {
+		return repo.UpstreamOwner
+	}
+	return m.upstreamOrg()
+}
+
+// ValidateAuth ensures that the git credentials are usable before doing any
+// network operation. When the ssh-agent is used, it verifies that the agent
+// holds at least one key. When an SSH key is configured, it loads the key,
+// which prompts for its passphrase if it's encrypted.
+func (m *Manager) ValidateAuth() error {
+	if m.sshAgent {
+		return ackdevgit.CheckSSHAgent()
+	}
+	if m.sshSigner == nil {
+		return nil
+	}
+	_, err := m.sshSigner()
+	return err
 }
 
 // LoadRepository loads information about a single local repository
@@ -109,10 +195,15 @@ func (m *Manager) LoadRepository(name string, t RepositoryType) (*Repository, er
 		}
 	}
 
-	repoName := name
-	// controller repositories should always have a '-controller' suffix
-	if t == RepositoryTypeController {
-		repoName = fmt.Sprintf("%s-controller", name)
+	overrides := m.cfg.Repositories.Overrides[name]
+
+	repoName := upstreamRepositoryName(name, t)
+	if overrides.UpstreamName != "" {
+		repoName = overrides.UpstreamName
+	}
+	upstreamOwner := m.upstreamOrg()
+	if overrides.UpstreamOwner != "" {
+		upstreamOwner = overrides.UpstreamOwner
 	}
 
 	// set expected fork name
@@ -120,26 +211,44 @@ func (m *Manager) LoadRepository(name string, t RepositoryType) (*Repository, er
 	if m.cfg.Github.ForkPrefix != "" {
 		expectedForkName = fmt.Sprintf("%s%s", m.cfg.Github.ForkPrefix, repoName)
 	}
+	if overrides.ForkName != "" {
+		expectedForkName = overrides.ForkName
+	}
 
 	var gitHead string
 	var gitRepo *git.Repository
+	var forked bool
+	state := RepositoryStateNotCloned
 	fullPath := filepath.Join(m.cfg.RootDirectory, repoName)
+	if overrides.Directory != "" {
+		fullPath = overrides.Directory
+		if !filepath.IsAbs(fullPath) {
+			fullPath = filepath.Join(m.cfg.RootDirectory, fullPath)
+		}
+	}
 
 	gitRepo, err = m.git.Open(fullPath)
 	if err != nil && err != git.ErrRepositoryNotExists {
 		return nil, err
 	} else if err == nil {
 		// load current branch
-		head, err := gitRepo.Head()
+		state, gitHead, err = loadHead(gitRepo)
+		if err != nil {
+			return nil, err
+		}
+		forked, err = isForkClone(gitRepo, upstreamOwner)
 		if err != nil {
 			return nil, err
 		}
-		gitHead = head.Name().Short()
 	}
 
 	repo = &Repository{
+		ConfigName:       name,
 		Name:             repoName,
+		UpstreamOwner:    upstreamOwner,
 		Type:             t,
+		State:            state,
+		Forked:           forked,
 		gitRepo:          gitRepo,
 		GitHead:          gitHead,
 		FullPath:         fullPath,
@@ -147,7 +256,9 @@ func (m *Manager) LoadRepository(name string, t RepositoryType) (*Repository, er
 	}
 
 	// cache repository
+	m.mu.Lock()
 	m.repoCache[name] = repo
+	m.mu.Unlock()
 	return repo, nil
 }
 
@@ -170,13 +281,21 @@ func (m *Manager) LoadAll() error {
 	return nil
 }
 
-// GetRepository return a known repository
+// GetRepository return a known repository, referenced by its configured name
+// or its full name.
 func (m *Manager) GetRepository(repoName string) (*Repository, error) {
-	repo, ok := m.repoCache[repoName]
-	if !ok {
-		return nil, ErrRepositoryNotCached
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if repo, ok := m.repoCache[repoName]; ok {
+		return repo, nil
 	}
-	return repo, nil
+	// Repositories can also be referenced by their full name (e.g s3-controller)
+	for _, repo := range m.repoCache {
+		if repo.Name == repoName {
+			return repo, nil
+		}
+	}
+	return nil, ErrRepositoryNotCached
 }
 
 // List returns the list of all the cached repositories
@@ -199,27 +318,24 @@ mainLoop:
 	return repos
 }
 
-// Clone clones a known repository to the config root directory
-func (m *Manager) clone(ctx context.Context, repoName string) error {
-	repo, err := m.GetRepository(repoName)
-	if err != nil {
-		return fmt.Errorf("cannot clone repository %s: %v", repoName, err)
-	}
+// clone clones a known repository fork into its expected local path and
+// adds the ACK upstream remote.
+func (m *Manager) clone(ctx context.Context, repo *Repository) error {
 	if repo.gitRepo != nil {
 		return ErrRepositoryAlreadyExist
 	}
 
 	// clone fork repository
-	err = m.git.Clone(
+	err := m.git.Clone(
 		ctx,
 		m.urlBuilder(m.cfg.Github.Username, repo.ExpectedForkName),
-		repo.ExpectedForkName,
+		repo.FullPath,
 	)
 	if errors.Is(err, transport.ErrAuthenticationRequired) {
 		return ErrUnauthenticated
 	}
 	if err != nil {
-		return fmt.Errorf("cannot clone repository %s: %v", repoName, err)
+		return fmt.Errorf("cannot clone repository %s: %v", repo.Name, err)
 	}
 
 	// open git repository
@@ -231,110 +347,153 @@ func (m *Manager) clone(ctx context.Context, repoName string) error {
 
 	// set repository git object
 	repo.gitRepo = gitRepo
+	repo.State, repo.GitHead, err = loadHead(gitRepo)
+	if err != nil {
+		return err
+	}
+	// origin points to the fork we just cloned
+	repo.Forked = true
 
 	// Add upstream remote
 	_, err = gitRepo.CreateRemote(&gitconfig.RemoteConfig{
 		Name: upstreamRemoteName,
-		URLs: []string{m.urlBuilder(github.ACKOrg, repo.Name)},
+		URLs: []string{m.urlBuilder(m.upstreamOwner(repo), repo.Name)},
 	})
 
 	if err != nil {
-		return fmt.Errorf("cannot add upstream remote to repository %s: %v", repoName, err)
+		return fmt.Errorf("cannot add upstream remote to repository %s: %v", repo.Name, err)
 	}
 
 	return nil
 }
 
-// ensureFork ensures that your github account have a fork for a given
+// EnsureFork ensures that your github account have a fork for a given
 // ACK project. It will also rename the project if it's not following the
 // standard: $ackprefix-$projectname
-func (m *Manager) EnsureFork(ctx context.Context, repo *Repository) error {
+// It returns the list of actions taken to ensure the fork.
+func (m *Manager) EnsureFork(ctx context.Context, repo *Repository) ([]EnsureAction, error) {
 	// TODO(hilaly): m.log.SetLevel(logrus.DebugLevel)
 
-	fork, err := m.ghc.GetUserRepositoryFork(ctx, m.cfg.Github.Username, repo.Name)
+	upstreamOwner := m.upstreamOwner(repo)
+	fork, err := m.ghc.GetUserRepositoryFork(ctx, m.cfg.Github.Username, upstreamOwner, repo.Name, repo.ExpectedForkName)
 	if err == nil {
 		if *fork.Name != repo.ExpectedForkName {
 			err = m.ghc.RenameRepository(ctx, m.cfg.Github.Username, *fork.Name, repo.ExpectedForkName)
 			if err != nil {
-				return err
+				return nil, err
 			}
+			return []EnsureAction{EnsureActionRenamed}, nil
 		}
+		return nil, nil
 	} else if err == github.ErrForkNotFound {
-		err = m.ghc.ForkRepository(ctx, repo.Name)
+		fork, err = m.ghc.ForkRepository(ctx, upstreamOwner, repo.Name)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		actions := []EnsureAction{EnsureActionForked}
 
-		time.Sleep(1 * time.Second)
+		// Github names the fork differently if the user already owns a
+		// repository named after the upstream one.
+		forkName := repo.Name
+		if fork.GetName() != "" {
+			forkName = fork.GetName()
+		}
 
-		err = m.ghc.RenameRepository(ctx, m.cfg.Github.Username, repo.Name, repo.ExpectedForkName)
+		// Github creates forks asynchronously, wait for the fork before
+		// renaming or cloning it.
+		_, err = m.ghc.WaitForFork(ctx, m.cfg.Github.Username, upstreamOwner, repo.Name, forkName)
 		if err != nil {
-			return err
+			return actions, err
 		}
-		return nil
+		if forkName == repo.ExpectedForkName {
+			return actions, nil
+		}
+
+		err = m.ghc.RenameRepository(ctx, m.cfg.Github.Username, forkName, repo.ExpectedForkName)
+		if err != nil {
+			return actions, err
+		}
+		return append(actions, EnsureActionRenamed), nil
 	}
-	return err
+	return nil, err
 }
 
-// used to help mocking os.Rename
-// TODO(hilalymh): Q4 switch to go1.16 os/fs library/interface
-var renameDirectory = os.Rename
-
-func (m *Manager) EnsureClone(ctx context.Context, repo *Repository) error {
-	err := m.clone(ctx, repo.Name)
-	if err != nil && err != ErrRepositoryAlreadyExist {
-		return err
+// EnsureClone ensures that the repository fork is cloned in its expected
+// local path. It returns the list of actions taken to ensure the clone.
+func (m *Manager) EnsureClone(ctx context.Context, repo *Repository) ([]EnsureAction, error) {
+	err := m.clone(ctx, repo)
+	if err == ErrRepositoryAlreadyExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	return []EnsureAction{EnsureActionCloned}, nil
+}
 
-	// At this point we ensured that the fork repository is cloned. We need to rename it
-	// if there is any fork prefix.
-	if repo.Name != repo.ExpectedForkName {
+// ensure forks and clones a single repository and records the actions
+// taken in the returned EnsureResult.
+func (m *Manager) ensure(ctx context.Context, repo *Repository) *EnsureResult {
+	res := &EnsureResult{Repository: repo}
 
-		newPath := filepath.Join(
-			filepath.Dir(repo.FullPath),
-			repo.Name,
-		)
-		err := renameDirectory(repo.FullPath, newPath)
-		if err != nil {
-			return err
-		}
+	// don't start any work if the context is already done
+	if err := ctx.Err(); err != nil {
+		res.Err = err
+		return res
 	}
 
-	return nil
-}
-
-// EnsureAll ensures one repository.
-func (m *Manager) EnsureRepository(ctx context.Context, name string) error {
-	repo, err := m.GetRepository(name)
+	actions, err := m.EnsureFork(ctx, repo)
+	res.Actions = append(res.Actions, actions...)
 	if err != nil {
-		return err
+		res.Err = err
+		return res
 	}
 
-	err = m.EnsureFork(ctx, repo)
+	actions, err = m.EnsureClone(ctx, repo)
+	res.Actions = append(res.Actions, actions...)
 	if err != nil {
-		return err
+		res.Err = err
 	}
+	return res
+}
 
-	err = m.EnsureClone(ctx, repo)
+// EnsureRepository ensures one repository.
+func (m *Manager) EnsureRepository(ctx context.Context, name string) (*EnsureResult, error) {
+	repo, err := m.GetRepository(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	res := m.ensure(ctx, repo)
+	return res, res.Err
 }
 
-// EnsureAll ensures all cached repositories.
-func (m *Manager) EnsureAll(ctx context.Context) error {
-	for _, repo := range m.repoCache {
-		err := m.EnsureFork(ctx, repo)
-		if err != nil {
-			return err
-		}
-
-		err = m.EnsureClone(ctx, repo)
-		if err != nil {
-			return err
+// Ensure ensures the given repositories using a pool of workers. The number of
+// workers is bounded to the number of repositories and can't be lower than 1.
+// A failing repository doesn't stop the others from being ensured, its error is
+// recorded in the returned EnsureResult. Results are returned in the same order
+// as the given repositories.
+func (m *Manager) Ensure(ctx context.Context, workers int, repos ...*Repository) []*EnsureResult {
+	results := make([]*EnsureResult, len(repos))
+
+	// fail fast if the git credentials are not usable
+	if err := m.ValidateAuth(); err != nil {
+		for i, repo := range repos {
+			results[i] = &EnsureResult{Repository: repo, Err: err}
 		}
+		return results
 	}
-	return nil
+
+	util.Parallelize(workers, len(repos), func(i int) {
+		results[i] = m.ensure(ctx, repos[i])
+	})
+	return results
+}
+
+// EnsureAll ensures all cached repositories using the given number of workers.
+// It returns the results of every repository and an *EnsureError listing all
+// the repositories that failed, if any.
+func (m *Manager) EnsureAll(ctx context.Context, workers int) ([]*EnsureResult, error) {
+	results := m.Ensure(ctx, workers, m.List()...)
+	return results, NewEnsureError(results)
 }
@@ -18,14 +18,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	gogithub "github.com/google/go-github/v35/github"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/src-d/go-billy.v4/memfs"
 	"gopkg.in/src-d/go-git.v4"
 	gitconfig "gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
 
 	"github.com/aws-controllers-k8s/dev-tools/pkg/config"
 	ackdevgit "github.com/aws-controllers-k8s/dev-tools/pkg/git"
@@ -48,14 +54,14 @@ func TestManager_LoadRepository(t *testing.T) {
 	testRepo, err := testutil.NewInMemoryGitRepository()
 	require.NoError(err)
 
-	fakeGit := &mocks.OpenCloner{}
+	fakeGit := &mocks.Client{}
 	fakeGit.On("Open", "runtime").Return(testRepo, nil)
 	fakeGit.On("Open", "s3-controller").Return(nil, git.ErrRepositoryNotExists)
 	fakeGit.On("Open", "sqs-controller").Return(nil, ErrUnconfiguredRepository)
 
 	type fields struct {
 		cfg       *config.Config
-		git       ackdevgit.OpenCloner
+		git       ackdevgit.Client
 		repoCache map[string]*Repository
 	}
 	type args struct {
@@ -131,7 +137,7 @@ func TestManager_LoadAll(t *testing.T) {
 	testRepo, err := testutil.NewInMemoryGitRepository()
 	require.NoError(err)
 
-	fakeGit := &mocks.OpenCloner{}
+	fakeGit := &mocks.Client{}
 	fakeGit.On("Open", "runtime").Return(testRepo, nil)
 	fakeGit.On("Open", "code-generator").Return(testRepo, nil)
 	fakeGit.On("Open", "s3-controller").Return(nil, git.ErrRepositoryNotExists)
@@ -140,7 +146,7 @@ func TestManager_LoadAll(t *testing.T) {
 
 	type fields struct {
 		cfg       *config.Config
-		git       ackdevgit.OpenCloner
+		git       ackdevgit.Client
 		repoCache map[string]*Repository
 	}
 	tests := []struct {
@@ -199,7 +205,7 @@ func TestManager_clone(t *testing.T) {
 	testRepo, err := testutil.NewInMemoryGitRepository()
 	require.NoError(err)
 
-	fakeGit := &mocks.OpenCloner{}
+	fakeGit := &mocks.Client{}
 	fakeGit.On("Open", "s3-controller").Return(testRepo, nil)
 	fakeGit.On("Open", "mq-controller").Return(nil, git.ErrRepositoryNotExists)
 	fakeGit.On("Open", "ecr-controller").Return(nil, git.ErrRepositoryNotExists)
@@ -210,25 +216,25 @@ func TestManager_clone(t *testing.T) {
 		"Clone",
 		testingCtx,
 		"https://github.com/ack-bot/ack-ecr-controller.git",
-		"ack-ecr-controller",
+		"ecr-controller",
 	).Return(transport.ErrAuthenticationRequired)
 	fakeGit.On(
 		"Clone",
 		testingCtx,
 		"https://github.com/ack-bot/ack-mq-controller.git",
-		"ack-mq-controller",
+		"mq-controller",
 	).Return(gitconfig.ErrRemoteConfigNotFound)
 	fakeGit.On(
 		"Clone",
 		testingCtx,
 		"https://github.com/ack-bot/ack-sagemaker-controller.git",
-		"ack-sagemaker-controller",
+		"sagemaker-controller",
 	).Return(nil)
 
 	type fields struct {
 		cfg        *config.Config
 		ghc        github.RepositoryService
-		git        ackdevgit.OpenCloner
+		git        ackdevgit.Client
 		urlBuilder func(string, string) string
 		repoCache  map[string]*Repository
 	}
@@ -270,7 +276,7 @@ func TestManager_clone(t *testing.T) {
 			fields: fields{
 				cfg:        testutil.NewConfig("s3", "elasticache"),
 				git:        fakeGit,
-				urlBuilder: httpsRemoteURL,
+				urlBuilder: httpsRemoteURLBuilder(config.DefaultGitHost),
 				repoCache:  make(map[string]*Repository),
 			},
 			args: args{
@@ -283,7 +289,7 @@ func TestManager_clone(t *testing.T) {
 			fields: fields{
 				cfg:        testutil.NewConfig("mq"),
 				git:        fakeGit,
-				urlBuilder: httpsRemoteURL,
+				urlBuilder: httpsRemoteURLBuilder(config.DefaultGitHost),
 				repoCache:  make(map[string]*Repository),
 			},
 			args: args{
@@ -296,7 +302,7 @@ func TestManager_clone(t *testing.T) {
 			fields: fields{
 				cfg:        testutil.NewConfig("sagemaker"),
 				git:        fakeGit,
-				urlBuilder: httpsRemoteURL,
+				urlBuilder: httpsRemoteURLBuilder(config.DefaultGitHost),
 				repoCache:  make(map[string]*Repository),
 			},
 			args: args{
@@ -314,8 +320,11 @@ func TestManager_clone(t *testing.T) {
 				urlBuilder: tt.fields.urlBuilder,
 				repoCache:  tt.fields.repoCache,
 			}
-			_, _ = m.LoadRepository(tt.args.repoName, RepositoryTypeController)
-			if err := m.clone(testingCtx, tt.args.repoName); (err != nil) != tt.wantErr {
+			repo, err := m.LoadRepository(tt.args.repoName, RepositoryTypeController)
+			if err == nil {
+				err = m.clone(testingCtx, repo)
+			}
+			if (err != nil) != tt.wantErr {
 				t.Errorf("Manager.clone() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -329,20 +338,25 @@ func TestManager_EnsureFork(t *testing.T) {
 		"GetUserRepositoryFork",
 		testingCtx,
 		"ack-bot",
+		"aws-controllers-k8s",
 		"s3-controller",
+		"s3-sagemaker-controller",
 	).Return(nil, github.ErrForkNotFound)
 	fakeGithubClient.On(
 		"ForkRepository",
 		testingCtx,
+		"aws-controllers-k8s",
 		"s3-controller",
-	).Return(errors.New("unknown error"))
+	).Return(nil, errors.New("unknown error"))
 
 	// sagemaker case
 	fakeGithubClient.On(
 		"GetUserRepositoryFork",
 		testingCtx,
 		"ack-bot",
+		"aws-controllers-k8s",
 		"sagemaker-controller",
+		"ack-sagemaker-controller",
 	).Return(&gogithub.Repository{Name: stringPtr("sagemaker-controller")}, nil)
 	fakeGithubClient.On(
 		"RenameRepository",
@@ -357,13 +371,24 @@ func TestManager_EnsureFork(t *testing.T) {
 		"GetUserRepositoryFork",
 		testingCtx,
 		"ack-bot",
+		"aws-controllers-k8s",
 		"ecr-controller",
+		"ack-ecr-controller",
 	).Return(nil, github.ErrForkNotFound)
 	fakeGithubClient.On(
 		"ForkRepository",
 		testingCtx,
+		"aws-controllers-k8s",
 		"ecr-controller",
-	).Return(nil)
+	).Return(&gogithub.Repository{Name: stringPtr("ecr-controller")}, nil)
+	fakeGithubClient.On(
+		"WaitForFork",
+		testingCtx,
+		"ack-bot",
+		"aws-controllers-k8s",
+		"ecr-controller",
+		"ecr-controller",
+	).Return(&gogithub.Repository{Name: stringPtr("ecr-controller")}, nil)
 	fakeGithubClient.On(
 		"RenameRepository",
 		testingCtx,
@@ -372,20 +397,76 @@ func TestManager_EnsureFork(t *testing.T) {
 		"ack-ecr-controller",
 	).Return(nil)
 
+	// sns case
+	fakeGithubClient.On(
+		"GetUserRepositoryFork",
+		testingCtx,
+		"ack-bot",
+		"aws-controllers-k8s",
+		"sns-controller",
+		"ack-sns-controller",
+	).Return(nil, github.ErrForkNotFound)
+	fakeGithubClient.On(
+		"ForkRepository",
+		testingCtx,
+		"aws-controllers-k8s",
+		"sns-controller",
+	).Return(&gogithub.Repository{Name: stringPtr("sns-controller")}, nil)
+	fakeGithubClient.On(
+		"WaitForFork",
+		testingCtx,
+		"ack-bot",
+		"aws-controllers-k8s",
+		"sns-controller",
+		"sns-controller",
+	).Return(nil, fmt.Errorf("%w: timeout", github.ErrForkNotReady))
+
+	// sqs case, the user already owns a sqs-controller repository
+	fakeGithubClient.On(
+		"GetUserRepositoryFork",
+		testingCtx,
+		"ack-bot",
+		"aws-controllers-k8s",
+		"sqs-controller",
+		"ack-sqs-controller",
+	).Return(nil, github.ErrForkNotFound)
+	fakeGithubClient.On(
+		"ForkRepository",
+		testingCtx,
+		"aws-controllers-k8s",
+		"sqs-controller",
+	).Return(&gogithub.Repository{Name: stringPtr("sqs-controller-1")}, nil)
+	fakeGithubClient.On(
+		"WaitForFork",
+		testingCtx,
+		"ack-bot",
+		"aws-controllers-k8s",
+		"sqs-controller",
+		"sqs-controller-1",
+	).Return(&gogithub.Repository{Name: stringPtr("sqs-controller-1")}, nil)
+	fakeGithubClient.On(
+		"RenameRepository",
+		testingCtx,
+		"ack-bot",
+		"sqs-controller-1",
+		"ack-sqs-controller",
+	).Return(nil)
+
 	type fields struct {
 		cfg       *config.Config
 		ghc       github.RepositoryService
-		git       ackdevgit.OpenCloner
+		git       ackdevgit.Client
 		repoCache map[string]*Repository
 	}
 	type args struct {
 		repo *Repository
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
+		name        string
+		fields      fields
+		args        args
+		wantErr     bool
+		wantActions []EnsureAction
 	}{
 		{
 			name: "fork error",
@@ -430,7 +511,40 @@ func TestManager_EnsureFork(t *testing.T) {
 					ExpectedForkName: "ack-ecr-controller",
 				},
 			},
-			wantErr: false,
+			wantErr:     false,
+			wantActions: []EnsureAction{EnsureActionForked, EnsureActionRenamed},
+		},
+		{
+			name: "fork still being created",
+			fields: fields{
+				cfg:       testutil.NewConfig("sns"),
+				ghc:       fakeGithubClient,
+				repoCache: make(map[string]*Repository),
+			},
+			args: args{
+				repo: &Repository{
+					Name:             "sns-controller",
+					ExpectedForkName: "ack-sns-controller",
+				},
+			},
+			wantErr:     true,
+			wantActions: []EnsureAction{EnsureActionForked},
+		},
+		{
+			name: "fork named differently by Github",
+			fields: fields{
+				cfg:       testutil.NewConfig("sqs"),
+				ghc:       fakeGithubClient,
+				repoCache: make(map[string]*Repository),
+			},
+			args: args{
+				repo: &Repository{
+					Name:             "sqs-controller",
+					ExpectedForkName: "ack-sqs-controller",
+				},
+			},
+			wantErr:     false,
+			wantActions: []EnsureAction{EnsureActionForked, EnsureActionRenamed},
 		},
 	}
 	for _, tt := range tests {
@@ -441,9 +555,491 @@ func TestManager_EnsureFork(t *testing.T) {
 				git:       tt.fields.git,
 				repoCache: tt.fields.repoCache,
 			}
-			if err := m.EnsureFork(testingCtx, tt.args.repo); (err != nil) != tt.wantErr {
+			actions, err := m.EnsureFork(testingCtx, tt.args.repo)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("Manager.ensureFork() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantActions != nil {
+				assert.Equal(t, tt.wantActions, actions)
+			}
 		})
 	}
+	// renaming must wait for the fork
+	fakeGithubClient.AssertNotCalled(t, "RenameRepository", testingCtx, "ack-bot", "sns-controller", "ack-sns-controller")
+}
+
+func TestManager_Ensure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	testRepo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(err)
+
+	fakeGithubClient := &mocks.RepositoryService{}
+	fakeGithubClient.On(
+		"GetUserRepositoryFork",
+		testingCtx,
+		"ack-bot",
+		"aws-controllers-k8s",
+		"s3-controller",
+		"ack-s3-controller",
+	).Return(nil, errors.New("unknown error"))
+	fakeGithubClient.On(
+		"GetUserRepositoryFork",
+		testingCtx,
+		"ack-bot",
+		"aws-controllers-k8s",
+		"ecr-controller",
+		"ack-ecr-controller",
+	).Return(&gogithub.Repository{Name: stringPtr("ack-ecr-controller")}, nil)
+
+	m := &Manager{
+		cfg: testutil.NewConfig("s3", "ecr"),
+		ghc: fakeGithubClient,
+	}
+	repos := []*Repository{
+		{
+			Name:             "s3-controller",
+			ExpectedForkName: "ack-s3-controller",
+		},
+		{
+			Name:             "ecr-controller",
+			ExpectedForkName: "ack-ecr-controller",
+			gitRepo:          testRepo,
+		},
+	}
+
+	results := m.Ensure(testingCtx, 1, repos...)
+	require.Len(results, 2)
+	assert.Error(results[0].Err)
+	assert.Equal("failed", results[0].Status())
+	assert.NoError(results[1].Err)
+	assert.Equal("skipped", results[1].Status())
+}
+
+func TestManager_EnsureAll_concurrent(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	testRepo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(err)
+
+	services := []string{"s3", "ecr", "sns", "sqs", "mq", "eks"}
+	failing := map[string]bool{"sns": true, "eks": true}
+
+	fakeGit := &mocks.Client{}
+	fakeGithubClient := &mocks.RepositoryService{}
+	for _, repoName := range []string{"runtime", "code-generator"} {
+		fakeGit.On("Open", repoName).Return(testRepo, nil)
+		fakeGithubClient.On(
+			"GetUserRepositoryFork",
+			testingCtx,
+			"ack-bot",
+			"aws-controllers-k8s",
+			repoName,
+			"ack-"+repoName,
+		).Return(&gogithub.Repository{Name: stringPtr("ack-" + repoName)}, nil)
+	}
+	for _, service := range services {
+		repoName := service + "-controller"
+		fakeGit.On("Open", repoName).Return(testRepo, nil)
+		if failing[service] {
+			fakeGithubClient.On(
+				"GetUserRepositoryFork",
+				testingCtx,
+				"ack-bot",
+				"aws-controllers-k8s",
+				repoName,
+				"ack-"+repoName,
+			).Return(nil, errors.New("unknown error"))
+			continue
+		}
+		fakeGithubClient.On(
+			"GetUserRepositoryFork",
+			testingCtx,
+			"ack-bot",
+			"aws-controllers-k8s",
+			repoName,
+			"ack-"+repoName,
+		).Return(&gogithub.Repository{Name: stringPtr("ack-" + repoName)}, nil)
+	}
+
+	m := &Manager{
+		cfg:       testutil.NewConfig(services...),
+		ghc:       fakeGithubClient,
+		git:       fakeGit,
+		repoCache: make(map[string]*Repository),
+	}
+	require.NoError(m.LoadAll())
+
+	results, err := m.EnsureAll(testingCtx, 3)
+	require.Len(results, 8)
+	require.Error(err)
+
+	ensureErr, ok := err.(*EnsureError)
+	require.True(ok)
+	require.Len(ensureErr.Failures, 2)
+	assert.Equal("sns-controller", ensureErr.Failures[0].Repository.Name)
+	assert.Equal("eks-controller", ensureErr.Failures[1].Repository.Name)
+	assert.Contains(err.Error(), "sns-controller: unknown error")
+	assert.Contains(err.Error(), "eks-controller: unknown error")
+}
+
+func TestManager_Ensure_invalidSSHKey(t *testing.T) {
+	assert := assert.New(t)
+
+	fakeGithubClient := &mocks.RepositoryService{}
+	m := &Manager{
+		cfg: testutil.NewConfig("s3"),
+		ghc: fakeGithubClient,
+		sshSigner: func() (ssh.Signer, error) {
+			return nil, errors.New("invalid ssh key")
+		},
+	}
+
+	assert.Error(m.ValidateAuth())
+	results := m.Ensure(testingCtx, 2, &Repository{Name: "runtime"}, &Repository{Name: "s3-controller"})
+	for _, res := range results {
+		assert.EqualError(res.Err, "invalid ssh key")
+	}
+	// no network call should be made
+	fakeGithubClient.AssertNotCalled(t, "GetUserRepositoryFork", testingCtx, "ack-bot", "aws-controllers-k8s", "runtime", "ack-runtime")
+}
+
+func TestManager_LoadRepository_states(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	branchRepo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(err)
+
+	detachedRepo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(err)
+	head, err := detachedRepo.Head()
+	require.NoError(err)
+	w, err := detachedRepo.Worktree()
+	require.NoError(err)
+	require.NoError(w.Checkout(&git.CheckoutOptions{Hash: head.Hash()}))
+
+	emptyRepo, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(err)
+
+	fakeGit := &mocks.Client{}
+	fakeGit.On("Open", "runtime").Return(branchRepo, nil)
+	fakeGit.On("Open", "code-generator").Return(detachedRepo, nil)
+	fakeGit.On("Open", "s3-controller").Return(emptyRepo, nil)
+	fakeGit.On("Open", "ecr-controller").Return(nil, git.ErrRepositoryNotExists)
+
+	m := &Manager{
+		cfg:       testutil.NewConfig("s3", "ecr"),
+		git:       fakeGit,
+		repoCache: make(map[string]*Repository),
+	}
+	require.NoError(m.LoadAll())
+
+	tests := []struct {
+		name        string
+		wantState   RepositoryState
+		wantGitHead string
+	}{
+		{"runtime", RepositoryStateBranch, "master"},
+		{"code-generator", RepositoryStateDetached, head.Hash().String()[:7]},
+		{"s3", RepositoryStateEmpty, "master"},
+		{"ecr", RepositoryStateNotCloned, ""},
+	}
+	for _, tt := range tests {
+		repo, err := m.GetRepository(tt.name)
+		require.NoError(err)
+		assert.Equal(tt.wantState, repo.State, tt.name)
+		assert.Equal(tt.wantGitHead, repo.GitHead, tt.name)
+	}
+}
+
+func TestManager_GetRepository(t *testing.T) {
+	assert := assert.New(t)
+
+	s3 := &Repository{Name: "s3-controller"}
+	m := &Manager{
+		repoCache: map[string]*Repository{"s3": s3},
+	}
+
+	for _, name := range []string{"s3", "s3-controller"} {
+		repo, err := m.GetRepository(name)
+		assert.NoError(err, name)
+		assert.Equal(s3, repo, name)
+	}
+
+	_, err := m.GetRepository("sns")
+	assert.Equal(ErrRepositoryNotCached, err)
+}
+
+func TestManager_LoadRepository_overrides(t *testing.T) {
+	fakeGit := &mocks.Client{}
+	fakeGit.On("Open", mock.Anything).Return(nil, git.ErrRepositoryNotExists)
+
+	tests := []struct {
+		name        string
+		upstreamOrg string
+		overrides   config.RepositoryOverrides
+		repoName    string
+		repoType    RepositoryType
+		want        *Repository
+	}{
+		{
+			name:     "default names",
+			repoName: "s3",
+			repoType: RepositoryTypeController,
+			want: &Repository{
+				ConfigName:       "s3",
+				Name:             "s3-controller",
+				UpstreamOwner:    "aws-controllers-k8s",
+				ExpectedForkName: "ack-s3-controller",
+				FullPath:         "/src/s3-controller",
+			},
+		},
+		{
+			name:        "upstream organisation",
+			upstreamOrg: "ack-preview",
+			repoName:    "s3",
+			repoType:    RepositoryTypeController,
+			want: &Repository{
+				ConfigName:       "s3",
+				Name:             "s3-controller",
+				UpstreamOwner:    "ack-preview",
+				ExpectedForkName: "ack-s3-controller",
+				FullPath:         "/src/s3-controller",
+			},
+		},
+		{
+			name:        "all overrides",
+			upstreamOrg: "ack-preview",
+			overrides: config.RepositoryOverrides{
+				UpstreamOwner: "someone",
+				UpstreamName:  "s3-preview",
+				Directory:     "s3",
+				ForkName:      "my-s3",
+			},
+			repoName: "s3",
+			repoType: RepositoryTypeController,
+			want: &Repository{
+				ConfigName:       "s3",
+				Name:             "s3-preview",
+				UpstreamOwner:    "someone",
+				ExpectedForkName: "my-s3",
+				FullPath:         "/src/s3",
+			},
+		},
+		{
+			name: "upstream name",
+			overrides: config.RepositoryOverrides{
+				UpstreamName: "ack-runtime",
+			},
+			repoName: "runtime",
+			repoType: RepositoryTypeCore,
+			want: &Repository{
+				ConfigName:       "runtime",
+				Name:             "ack-runtime",
+				UpstreamOwner:    "aws-controllers-k8s",
+				ExpectedForkName: "ack-ack-runtime",
+				FullPath:         "/src/ack-runtime",
+			},
+		},
+		{
+			name: "absolute directory",
+			overrides: config.RepositoryOverrides{
+				Directory: "/work/runtime",
+			},
+			repoName: "runtime",
+			repoType: RepositoryTypeCore,
+			want: &Repository{
+				ConfigName:       "runtime",
+				Name:             "runtime",
+				UpstreamOwner:    "aws-controllers-k8s",
+				ExpectedForkName: "ack-runtime",
+				FullPath:         "/work/runtime",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.NewConfig("s3")
+			cfg.RootDirectory = "/src"
+			cfg.Github.UpstreamOrg = tt.upstreamOrg
+			cfg.Repositories.Overrides = map[string]config.RepositoryOverrides{
+				tt.repoName: tt.overrides,
+			}
+			m := &Manager{
+				cfg:       cfg,
+				git:       fakeGit,
+				repoCache: make(map[string]*Repository),
+			}
+
+			repo, err := m.LoadRepository(tt.repoName, tt.repoType)
+			require.NoError(t, err)
+			tt.want.Type = tt.repoType
+			tt.want.State = RepositoryStateNotCloned
+			assert.Equal(t, tt.want, repo)
+		})
+	}
+}
+
+func TestManager_EnsureFork_upstreamOverrides(t *testing.T) {
+	testRepo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(t, err)
+
+	cfg := testutil.NewConfig("s3")
+	cfg.Github.UpstreamOrg = "ack-preview"
+	cfg.Repositories.Overrides = map[string]config.RepositoryOverrides{
+		"s3": {UpstreamOwner: "someone", UpstreamName: "s3-preview", ForkName: "my-s3"},
+	}
+
+	fakeGit := &mocks.Client{}
+	fakeGit.On("Open", "s3-preview").Return(nil, git.ErrRepositoryNotExists).Once()
+	fakeGit.On("Open", "s3-preview").Return(testRepo, nil).Once()
+	fakeGit.On("Clone", testingCtx, "https://github.com/ack-bot/my-s3.git", "s3-preview").Return(nil)
+
+	fakeGithubClient := &mocks.RepositoryService{}
+	fakeGithubClient.On(
+		"GetUserRepositoryFork",
+		testingCtx,
+		"ack-bot",
+		"someone",
+		"s3-preview",
+		"my-s3",
+	).Return(nil, github.ErrForkNotFound)
+	fakeGithubClient.On("ForkRepository", testingCtx, "someone", "s3-preview").Return(&gogithub.Repository{Name: stringPtr("s3-preview")}, nil)
+	fakeGithubClient.On("WaitForFork", testingCtx, "ack-bot", "someone", "s3-preview", "s3-preview").Return(&gogithub.Repository{}, nil)
+	fakeGithubClient.On("RenameRepository", testingCtx, "ack-bot", "s3-preview", "my-s3").Return(nil)
+
+	m := &Manager{
+		cfg:        cfg,
+		ghc:        fakeGithubClient,
+		git:        fakeGit,
+		urlBuilder: httpsRemoteURLBuilder(config.DefaultGitHost),
+		repoCache:  make(map[string]*Repository),
+	}
+	repo, err := m.LoadRepository("s3", RepositoryTypeController)
+	require.NoError(t, err)
+
+	actions, err := m.EnsureFork(testingCtx, repo)
+	require.NoError(t, err)
+	assert.Equal(t, []EnsureAction{EnsureActionForked, EnsureActionRenamed}, actions)
+
+	require.NoError(t, m.clone(testingCtx, repo))
+	remote, err := testRepo.Remote(upstreamRemoteName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://github.com/someone/s3-preview.git"}, remote.Config().URLs)
+	fakeGithubClient.AssertExpectations(t)
+}
+
+func TestNewManager_githubEndpoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		github        config.GithubConfig
+		sshKeyPath    string
+		wantRemoteURL string
+		wantErr       bool
+	}{
+		{
+			name:          "github.com",
+			wantRemoteURL: "https://github.com/aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:          "github.com over ssh",
+			sshKeyPath:    "/tmp/id_rsa",
+			wantRemoteURL: "git@github.com:aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:          "api.github.com base URL",
+			github:        config.GithubConfig{BaseURL: "https://api.github.com/"},
+			wantRemoteURL: "https://github.com/aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:          "enterprise host from base URL",
+			github:        config.GithubConfig{BaseURL: "https://ghe.example.com/api/v3/"},
+			wantRemoteURL: "https://ghe.example.com/aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:          "enterprise git host",
+			github:        config.GithubConfig{BaseURL: "https://api.ghe.example.com", GitHost: "git.example.com"},
+			sshKeyPath:    "/tmp/id_rsa",
+			wantRemoteURL: "git@git.example.com:aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:          "enterprise base URL with a port",
+			github:        config.GithubConfig{BaseURL: "https://ghe.example.com:8443/api/v3/"},
+			wantRemoteURL: "https://ghe.example.com:8443/aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:          "enterprise base URL with a port over ssh",
+			github:        config.GithubConfig{BaseURL: "https://ghe.example.com:8443/api/v3/"},
+			sshKeyPath:    "/tmp/id_rsa",
+			wantRemoteURL: "git@ghe.example.com:aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:          "git host with an ssh port",
+			github:        config.GithubConfig{GitHost: "git.example.com:2222"},
+			sshKeyPath:    "/tmp/id_rsa",
+			wantRemoteURL: "ssh://git@git.example.com:2222/aws-controllers-k8s/s3-controller.git",
+		},
+		{
+			name:    "invalid base URL",
+			github:  config.GithubConfig{BaseURL: "ghe.example.com"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.NewConfig("s3")
+			cfg.Github.BaseURL = tt.github.BaseURL
+			cfg.Github.GitHost = tt.github.GitHost
+			cfg.Git.SSHKeyPath = tt.sshKeyPath
+
+			m, err := NewManager(cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRemoteURL, m.urlBuilder(m.upstreamOrg(), "s3-controller"))
+		})
+	}
+}
+
+func TestManager_EnsureFork_githubCompatibleServer(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v3/repos/ack-bot/ack-s3-controller":
+			fmt.Fprint(w, `{"name": "ack-s3-controller", "fork": true, "parent": {"name": "s3-controller", "owner": {"login": "aws-controllers-k8s"}}}`)
+		case "/api/v3/repos/ack-bot/ecr-controller", "/api/v3/repos/ack-bot/ack-ecr-controller":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v3/user/repos":
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	cfg := testutil.NewConfig("s3", "ecr")
+	cfg.Github.BaseURL = server.URL + "/api/v3"
+	m, err := NewManager(cfg)
+	require.NoError(t, err)
+
+	// the fork exists with the expected name, nothing to do
+	actions, err := m.EnsureFork(testingCtx, &Repository{Name: "s3-controller", ExpectedForkName: "ack-s3-controller"})
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+
+	_, err = m.ghc.GetUserRepositoryFork(testingCtx, "ack-bot", "", "ecr-controller", "ack-ecr-controller")
+	assert.Equal(t, github.ErrForkNotFound, err)
+	assert.Equal(t, []string{
+		"GET /api/v3/repos/ack-bot/ack-s3-controller",
+		"GET /api/v3/repos/ack-bot/ack-ecr-controller",
+		"GET /api/v3/repos/ack-bot/ecr-controller",
+		"GET /api/v3/user/repos",
+	}, paths)
 }
@@ -15,42 +15,154 @@ package repository
 
 import (
 	"fmt"
+	"net"
+	"strings"
 
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 )
 
-// NewRepository returns a pointer to a new repository.
+const (
+	// abbreviatedHashLength is the length of the abbreviated commit hashes
+	abbreviatedHashLength = 7
+)
+
+// NewRepository returns a pointer to a new repository, named following the ACK
+// naming rules. Use Manager.LoadRepository to honour the configured overrides.
 func NewRepository(name string, repoType RepositoryType) *Repository {
-	if repoType == RepositoryTypeController {
-		name = fmt.Sprintf("%s-controller", name)
-	}
 	return &Repository{
-		Name: name,
-		Type: repoType,
+		ConfigName: name,
+		Name:       upstreamRepositoryName(name, repoType),
+		Type:       repoType,
 	}
 }
 
+// upstreamRepositoryName returns the default upstream name of a repository.
+// Controller repositories always have a '-controller' suffix.
+func upstreamRepositoryName(name string, repoType RepositoryType) string {
+	if repoType == RepositoryTypeController {
+		return fmt.Sprintf("%s-controller", name)
+	}
+	return name
+}
+
 // Repository represents an ACK project repository.
 type Repository struct {
 	// this field might be nil, if the repository doesn't exist locally
 	gitRepo *git.Repository
 
+	// Name of the repository in the ackdev configuration (e.g s3)
+	ConfigName string `json:"configName"`
 	// Name of the ACK upstream repo
-	Name string
+	Name string `json:"name"`
+	// Owner of the upstream repo. When it's empty the repository is owned by
+	// the configured upstream organisation.
+	UpstreamOwner string `json:"upstreamOwner,omitempty"`
 	// Repository Type
-	Type RepositoryType
+	Type RepositoryType `json:"type"`
 	// Expected fork name. Generally looking like ack-sagemaker
-	ExpectedForkName string
+	ExpectedForkName string `json:"expectedForkName"`
 	// Expected local full path
-	FullPath string
-	// Git HEAD commit or current branch
-	GitHead string
+	FullPath string `json:"fullPath"`
+	// State of the local repository
+	State RepositoryState `json:"state"`
+	// Git HEAD commit or current branch. When the repository is empty it
+	// contains the name of the unborn branch, when HEAD is detached it contains
+	// the abbreviated commit hash.
+	GitHead string `json:"gitHead"`
+	// Forked is true if the local repository origin remote points to a fork
+	// of the upstream repository.
+	Forked bool `json:"forked"`
+	// Status of the local repository. It is nil until the status is
+	// loaded by the Manager.
+	Status *Status `json:"status,omitempty"`
+}
+
+// httpsRemoteURLBuilder returns a function building the HTTPS remote URLs of
+// repositories hosted on host.
+func httpsRemoteURLBuilder(host string) func(owner, name string) string {
+	return func(owner, name string) string {
+		return fmt.Sprintf("https://%s/%s/%s.git", host, owner, name)
+	}
+}
+
+// sshRemoteURLBuilder returns a function building the SSH remote URLs of
+// repositories hosted on host. The scp like syntax can't specify a port, the
+// ssh:// syntax is used when host contains one.
+func sshRemoteURLBuilder(host string) func(owner, name string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return func(owner, name string) string {
+			return fmt.Sprintf("ssh://git@%s/%s/%s.git", host, owner, name)
+		}
+	}
+	return func(owner, name string) string {
+		return fmt.Sprintf("git@%s:%s/%s.git", host, owner, name)
+	}
 }
 
-func httpsRemoteURL(owner, name string) string {
-	return fmt.Sprintf("https://github.com/%s/%s.git", owner, name)
+// loadHead returns the state of a local git repository along with its
+// current branch, or its abbreviated HEAD commit hash if HEAD is detached.
+func loadHead(gitRepo *git.Repository) (RepositoryState, string, error) {
+	head, err := gitRepo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		// HEAD points to a branch that doesn't exist yet
+		head, err = gitRepo.Reference(plumbing.HEAD, false)
+		if err != nil {
+			return RepositoryStateEmpty, "", err
+		}
+		return RepositoryStateEmpty, head.Target().Short(), nil
+	}
+	if err != nil {
+		return RepositoryStateNotCloned, "", err
+	}
+	if head.Name().IsBranch() {
+		return RepositoryStateBranch, head.Name().Short(), nil
+	}
+	return RepositoryStateDetached, head.Hash().String()[:abbreviatedHashLength], nil
 }
 
-func sshRemoteURL(owner, name string) string {
-	return fmt.Sprintf("git@github.com:%s/%s.git", owner, name)
+// isForkClone returns true if the origin remote of a local git repository
+// points to a repository that isn't owned by the upstream owner.
+func isForkClone(gitRepo *git.Repository, upstreamOwner string) (bool, error) {
+	remote, err := gitRepo.Remote(originRemoteName)
+	if err == git.ErrRemoteNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, url := range remote.Config().URLs {
+		owner := remoteURLOwner(url)
+		if owner != "" && owner != upstreamOwner {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// remoteURLOwner returns the owner of a repository remote URL. It supports
+// https://host/owner/repo.git, ssh://git@host/owner/repo.git and the scp
+// like syntax git@host:owner/repo.git. It returns an empty string if the URL
+// cannot be parsed.
+func remoteURLOwner(url string) string {
+	var path string
+	if i := strings.Index(url, "://"); i >= 0 {
+		// strip the scheme and the host
+		path = url[i+len("://"):]
+		j := strings.Index(path, "/")
+		if j < 0 {
+			return ""
+		}
+		path = path[j+1:]
+	} else if i := strings.Index(url, ":"); i >= 0 {
+		path = url[i+1:]
+	} else {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
 }
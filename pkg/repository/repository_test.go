@@ -0,0 +1,52 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteURLOwner(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/ack-bot/ack-s3-controller.git":           "ack-bot",
+		"git@github.com:ack-bot/ack-s3-controller.git":               "ack-bot",
+		"ssh://git@github.com/aws-controllers-k8s/s3-controller.git": "aws-controllers-k8s",
+		"https://ghe.example.com/api/ack-bot/runtime":                "ack-bot",
+		"/some/local/path":   "",
+		"https://github.com": "",
+	}
+	for url, want := range tests {
+		assert.Equal(t, want, remoteURLOwner(url), url)
+	}
+}
+
+func TestRemoteURLBuilders(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder func(owner, name string) string
+		want    string
+	}{
+		{"https", httpsRemoteURLBuilder("github.com"), "https://github.com/ack-bot/runtime.git"},
+		{"https with a port", httpsRemoteURLBuilder("ghe.example.com:8443"), "https://ghe.example.com:8443/ack-bot/runtime.git"},
+		{"ssh", sshRemoteURLBuilder("github.com"), "git@github.com:ack-bot/runtime.git"},
+		{"ssh with a port", sshRemoteURLBuilder("ghe.example.com:2222"), "ssh://git@ghe.example.com:2222/ack-bot/runtime.git"},
+	}
+	for _, tt := range tests {
+		url := tt.builder("ack-bot", "runtime")
+		assert.Equal(t, tt.want, url, tt.name)
+		assert.Equal(t, "ack-bot", remoteURLOwner(url), tt.name)
+	}
+}
@@ -13,20 +13,50 @@
 
 package repository
 
-import "sort"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnknownSortField error = errors.New("unknown sort field")
+)
 
 // Gently stolen from github.com/aws-controllers-k8s/code-generator/pkg/model/printer_column.go
 
 // By can sort two Repositories
 type By func(a, b *Repository) bool
 
-// Sort does an in-place sort of the supplied repositories
+// Sort does an in-place stable sort of the supplied repositories
 func (by By) Sort(subject []*Repository) {
 	pcs := repositorySorter{
 		cols: subject,
 		by:   by,
 	}
-	sort.Sort(pcs)
+	sort.Stable(pcs)
+}
+
+// Reverse returns a By sorting repositories in the reverse order
+func (by By) Reverse() By {
+	return func(a, b *Repository) bool {
+		return by(b, a)
+	}
+}
+
+// Then returns a By sorting repositories using by, and using next to sort
+// the repositories that are equal according to by.
+func (by By) Then(next By) By {
+	return func(a, b *Repository) bool {
+		if by(a, b) {
+			return true
+		}
+		if by(b, a) {
+			return false
+		}
+		return next(a, b)
+	}
 }
 
 // repositorySorter sorts repositories
@@ -65,16 +95,58 @@ func ByType(a, b *Repository) bool {
 	return a.Type < b.Type
 }
 
+// Sort two repositories by state
+func ByState(a, b *Repository) bool {
+	return a.State < b.State
+}
+
 // SortBy takes a field path and returns the equivalent Sorter function
-func SortBy(fieldPath string) By {
+func SortBy(fieldPath string) (By, error) {
 	switch fieldPath {
 	case "name":
-		return ByName
+		return ByName, nil
 	case "branch":
-		return ByBranch
+		return ByBranch, nil
 	case "type":
-		return ByType
+		return ByType, nil
+	case "state":
+		return ByState, nil
 	default:
-		panic("unknown sort field")
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSortField, fieldPath)
+	}
+}
+
+// ParseSortBy takes a comma separated list of field paths and returns a By
+// sorting repositories by all the fields, in order. A field prefixed with '-'
+// is sorted in descending order, a field optionally prefixed with '+' is
+// sorted in ascending order. Example: "type,-branch,name"
+func ParseSortBy(expression string) (By, error) {
+	var by By
+	for _, field := range strings.Split(expression, ",") {
+		field = strings.TrimSpace(field)
+		descending := strings.HasPrefix(field, "-")
+		if descending {
+			field = strings.TrimPrefix(field, "-")
+		} else {
+			field = strings.TrimPrefix(field, "+")
+		}
+		if field == "" {
+			return nil, fmt.Errorf("%w: empty field in %q", ErrUnknownSortField, expression)
+		}
+
+		fieldBy, err := SortBy(field)
+		if err != nil {
+			return nil, err
+		}
+		if descending {
+			fieldBy = fieldBy.Reverse()
+		}
+
+		if by == nil {
+			by = fieldBy
+		} else {
+			by = by.Then(fieldBy)
+		}
 	}
+	return by, nil
 }
@@ -0,0 +1,93 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func repositoryNames(repos []*Repository) []string {
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+func TestParseSortBy(t *testing.T) {
+	newRepos := func() []*Repository {
+		return []*Repository{
+			{Name: "s3-controller", Type: RepositoryTypeController, GitHead: "main"},
+			{Name: "runtime", Type: RepositoryTypeCore, GitHead: "main"},
+			{Name: "ecr-controller", Type: RepositoryTypeController, GitHead: "feature"},
+			{Name: "code-generator", Type: RepositoryTypeCore, GitHead: "feature"},
+			{Name: "sns-controller", Type: RepositoryTypeController, GitHead: "main"},
+		}
+	}
+
+	tests := []struct {
+		expression string
+		want       []string
+	}{
+		{
+			expression: "name",
+			want:       []string{"code-generator", "ecr-controller", "runtime", "s3-controller", "sns-controller"},
+		},
+		{
+			expression: "+name",
+			want:       []string{"code-generator", "ecr-controller", "runtime", "s3-controller", "sns-controller"},
+		},
+		{
+			expression: "-name",
+			want:       []string{"sns-controller", "s3-controller", "runtime", "ecr-controller", "code-generator"},
+		},
+		{
+			// stable: equal repositories keep their original order
+			expression: "type",
+			want:       []string{"runtime", "code-generator", "s3-controller", "ecr-controller", "sns-controller"},
+		},
+		{
+			expression: "type,-branch,+name",
+			want:       []string{"runtime", "code-generator", "s3-controller", "sns-controller", "ecr-controller"},
+		},
+		{
+			expression: " branch , name ",
+			want:       []string{"code-generator", "ecr-controller", "runtime", "s3-controller", "sns-controller"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			by, err := ParseSortBy(tt.expression)
+			require.NoError(t, err)
+
+			repos := newRepos()
+			by.Sort(repos)
+			assert.Equal(t, tt.want, repositoryNames(repos))
+		})
+	}
+}
+
+func TestParseSortBy_errors(t *testing.T) {
+	for _, expression := range []string{
+		"", "name,", "-", "+", "name,unknown", "size",
+		"--name", "++name", "+-name", "-+branch", "type,--state",
+	} {
+		_, err := ParseSortBy(expression)
+		assert.True(t, errors.Is(err, ErrUnknownSortField), "expression %q: unexpected error %v", expression, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	ackdevgit "github.com/aws-controllers-k8s/dev-tools/pkg/git"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/util"
+)
+
+// Divergence holds the number of commits a branch is ahead and behind
+// another branch.
+type Divergence struct {
+	// Ahead is the number of commits that are only present locally
+	Ahead int `json:"ahead"`
+	// Behind is the number of commits that are only present remotely
+	Behind int `json:"behind"`
+}
+
+// Status describes the state of a local repository worktree and how its
+// current branch compares with the remotes.
+type Status struct {
+	// Dirty is true if the worktree contains uncommitted changes to tracked files
+	Dirty bool `json:"dirty"`
+	// UntrackedFiles is the number of untracked files in the worktree
+	UntrackedFiles int `json:"untrackedFiles"`
+	// Upstream compares HEAD with upstream/main. It is nil if the reference
+	// doesn't exist.
+	Upstream *Divergence `json:"upstream"`
+	// Origin compares HEAD with the origin branch of the same name. It is nil
+	// if the reference doesn't exist.
+	Origin *Divergence `json:"origin"`
+	// LastCommitSubject is the first line of the HEAD commit message
+	LastCommitSubject string `json:"lastCommitSubject"`
+	// LastCommitDate is the HEAD commit author date
+	LastCommitDate time.Time `json:"lastCommitDate"`
+}
+
+// LoadStatus loads the Status of the given repositories using a pool of
+// workers. Repositories that are not cloned are ignored. It returns the
+// first encountered error, if any, after all the repositories are loaded.
+func (m *Manager) LoadStatus(workers int, repos ...*Repository) error {
+	var mu sync.Mutex
+	var firstErr error
+	util.Parallelize(workers, len(repos), func(i int) {
+		err := repos[i].loadStatus()
+		if err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+		}
+	})
+	return firstErr
+}
+
+// loadStatus computes the worktree status of the repository and compares
+// its HEAD with the upstream and origin remotes.
+func (r *Repository) loadStatus() error {
+	if r.gitRepo == nil {
+		return nil
+	}
+
+	// head is nil if the repository doesn't have any commit yet
+	head, err := r.gitRepo.Head()
+	if err != nil && err != plumbing.ErrReferenceNotFound {
+		return err
+	}
+
+	status := &Status{}
+
+	worktree, err := r.gitRepo.Worktree()
+	if err != nil {
+		return err
+	}
+	worktreeStatus, err := worktree.Status()
+	if err != nil {
+		return err
+	}
+	var untracked []string
+	status.Dirty, untracked = worktreeChanges(worktreeStatus)
+	status.UntrackedFiles = len(untracked)
+
+	if head == nil {
+		r.Status = status
+		return nil
+	}
+
+	commit, err := r.gitRepo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	status.LastCommitSubject = strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+	status.LastCommitDate = commit.Author.When
+
+	upstreamRefName := plumbing.NewRemoteReferenceName(upstreamRemoteName, defaultBranchName)
+	status.Upstream, err = r.divergence(head.Hash(), upstreamRefName)
+	if err != nil {
+		return err
+	}
+	if head.Name().IsBranch() {
+		originRefName := plumbing.NewRemoteReferenceName(originRemoteName, head.Name().Short())
+		status.Origin, err = r.divergence(head.Hash(), originRefName)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.Status = status
+	return nil
+}
+
+// divergence compares a commit with a remote reference. It returns nil if
+// the reference doesn't exist.
+func (r *Repository) divergence(local plumbing.Hash, remoteRefName plumbing.ReferenceName) (*Divergence, error) {
+	remoteRef, err := r.gitRepo.Reference(remoteRefName, true)
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ahead, behind, err := ackdevgit.AheadBehind(r.gitRepo, local, remoteRef.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return &Divergence{Ahead: ahead, Behind: behind}, nil
+}
+
+// worktreeChanges returns whether a worktree status contains changes to
+// tracked files and the list of untracked files.
+func worktreeChanges(status git.Status) (dirty bool, untracked []string) {
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked {
+			untracked = append(untracked, path)
+			continue
+		}
+		if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			dirty = true
+		}
+	}
+	return dirty, untracked
+}
@@ -0,0 +1,74 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/testutil"
+)
+
+func TestManager_LoadStatus(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// main is 2 commits behind upstream/main
+	repo := newTestSyncRepository(t, 2)
+	head, err := repo.Head()
+	require.NoError(err)
+	// origin/main is 1 commit behind main
+	require.NoError(repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewRemoteReferenceName("origin", "main"),
+		head.Hash(),
+	)))
+	_, err = testutil.CommitFile(repo, "local.txt", "local", "add local file\n\nsome details")
+	require.NoError(err)
+	require.NoError(testutil.WriteFile(repo, "ramanujan_serie.txt", "1 + 1 = 2"))
+	require.NoError(testutil.WriteFile(repo, "untracked-1.txt", "untracked"))
+	require.NoError(testutil.WriteFile(repo, "untracked-2.txt", "untracked"))
+
+	cleanRepo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(err)
+
+	clonedRepo := &Repository{Name: "s3-controller", gitRepo: repo}
+	cleanClonedRepo := &Repository{Name: "runtime", gitRepo: cleanRepo}
+	notClonedRepo := &Repository{Name: "ecr-controller"}
+
+	m := &Manager{cfg: testutil.NewConfig()}
+	require.NoError(m.LoadStatus(2, clonedRepo, cleanClonedRepo, notClonedRepo))
+
+	status := clonedRepo.Status
+	require.NotNil(status)
+	assert.True(status.Dirty)
+	assert.Equal(2, status.UntrackedFiles)
+	assert.Equal("add local file", status.LastCommitSubject)
+	assert.False(status.LastCommitDate.IsZero())
+	require.NotNil(status.Upstream)
+	assert.Equal(Divergence{Ahead: 1, Behind: 2}, *status.Upstream)
+	require.NotNil(status.Origin)
+	assert.Equal(Divergence{Ahead: 1, Behind: 0}, *status.Origin)
+
+	status = cleanClonedRepo.Status
+	require.NotNil(status)
+	assert.False(status.Dirty)
+	assert.Equal(0, status.UntrackedFiles)
+	assert.Nil(status.Upstream)
+	assert.Nil(status.Origin)
+
+	assert.Nil(notClonedRepo.Status)
+}
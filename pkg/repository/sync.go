@@ -0,0 +1,208 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	ackdevgit "github.com/aws-controllers-k8s/dev-tools/pkg/git"
+	"github.com/aws-controllers-k8s/dev-tools/pkg/util"
+)
+
+// SyncStatus is the outcome of a repository synchronisation.
+type SyncStatus string
+
+const (
+	// SyncStatusSynced means that the default branch was fast-forwarded.
+	SyncStatusSynced SyncStatus = "synced"
+	// SyncStatusUpToDate means that the default branch was already up to date.
+	SyncStatusUpToDate SyncStatus = "up-to-date"
+	// SyncStatusSkipped means that the repository was left untouched.
+	SyncStatusSkipped SyncStatus = "skipped"
+	// SyncStatusFailed means that an error occurred during the synchronisation.
+	SyncStatusFailed SyncStatus = "failed"
+)
+
+// SyncResult holds the outcome of synchronising a single repository.
+type SyncResult struct {
+	// Repository is the synchronised repository
+	Repository *Repository
+	// Status is the outcome of the synchronisation
+	Status SyncStatus
+	// Reason explains why the repository was skipped
+	Reason string
+	// Ahead is the number of local commits that are not present upstream
+	Ahead int
+	// Behind is the number of upstream commits that were not present locally
+	// before the synchronisation
+	Behind int
+	// Err is the error that interrupted the synchronisation, if any.
+	Err error
+}
+
+// Sync fetches the upstream remote of the given repositories, fast-forwards
+// their local default branch and pushes it to origin. Repositories that are not
+// cloned, are not on the default branch, have uncommitted changes or local
+// commits that are not present upstream are skipped. Repositories are
+// synchronised using a pool of workers and results are returned in the same
+// order as the given repositories.
+func (m *Manager) Sync(ctx context.Context, workers int, repos ...*Repository) []*SyncResult {
+	results := make([]*SyncResult, len(repos))
+
+	// fail fast if the git credentials are not usable
+	if err := m.ValidateAuth(); err != nil {
+		for i, repo := range repos {
+			results[i] = &SyncResult{Repository: repo, Status: SyncStatusFailed, Err: err}
+		}
+		return results
+	}
+
+	util.Parallelize(workers, len(repos), func(i int) {
+		results[i] = m.sync(ctx, repos[i])
+	})
+	return results
+}
+
+// sync synchronises a single repository default branch with upstream.
+func (m *Manager) sync(ctx context.Context, repo *Repository) *SyncResult {
+	res := &SyncResult{Repository: repo}
+	skip := func(reason string) *SyncResult {
+		res.Status = SyncStatusSkipped
+		res.Reason = reason
+		return res
+	}
+	fail := func(err error) *SyncResult {
+		res.Status = SyncStatusFailed
+		res.Err = err
+		return res
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+	if repo.gitRepo == nil {
+		return skip("repository is not cloned")
+	}
+
+	branchRefName := plumbing.NewBranchReferenceName(defaultBranchName)
+	head, err := repo.gitRepo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return skip("repository has no commits")
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if !head.Name().IsBranch() {
+		return skip(fmt.Sprintf("HEAD is detached at %s", head.Hash().String()[:abbreviatedHashLength]))
+	}
+	if head.Name() != branchRefName {
+		return skip(fmt.Sprintf("current branch is %s", head.Name().Short()))
+	}
+
+	worktree, err := repo.gitRepo.Worktree()
+	if err != nil {
+		return fail(err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return fail(err)
+	}
+	dirty, untracked := worktreeChanges(status)
+	if dirty {
+		return skip("worktree has uncommitted changes")
+	}
+
+	err = m.git.Fetch(ctx, repo.gitRepo, upstreamRemoteName)
+	if err != nil {
+		return fail(fmt.Errorf("cannot fetch %s: %v", upstreamRemoteName, err))
+	}
+
+	upstreamRefName := plumbing.NewRemoteReferenceName(upstreamRemoteName, defaultBranchName)
+	upstreamRef, err := repo.gitRepo.Reference(upstreamRefName, true)
+	if err != nil {
+		return fail(fmt.Errorf("cannot find %s: %v", upstreamRefName.Short(), err))
+	}
+
+	res.Ahead, res.Behind, err = ackdevgit.AheadBehind(repo.gitRepo, head.Hash(), upstreamRef.Hash())
+	if err != nil {
+		return fail(err)
+	}
+	if res.Ahead > 0 {
+		return skip(fmt.Sprintf("%s cannot be fast-forwarded", defaultBranchName))
+	}
+
+	if res.Behind > 0 {
+		overwritten, err := overwrittenFiles(repo.gitRepo, upstreamRef.Hash(), untracked)
+		if err != nil {
+			return fail(err)
+		}
+		if len(overwritten) > 0 {
+			return skip(fmt.Sprintf("untracked files would be overwritten: %s", strings.Join(overwritten, ", ")))
+		}
+
+		// The worktree is clean, a hard reset is equivalent to a fast-forward.
+		err = worktree.Reset(&git.ResetOptions{
+			Commit: upstreamRef.Hash(),
+			Mode:   git.HardReset,
+		})
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	refSpec := fmt.Sprintf("%s:%s", branchRefName, branchRefName)
+	err = m.git.Push(ctx, repo.gitRepo, originRemoteName, refSpec)
+	if err != nil {
+		return fail(fmt.Errorf("cannot push to %s: %v", originRemoteName, err))
+	}
+
+	if res.Behind > 0 {
+		res.Status = SyncStatusSynced
+	} else {
+		res.Status = SyncStatusUpToDate
+	}
+	return res
+}
+
+// overwrittenFiles returns the paths, among the given ones, that exist in
+// the tree of the given commit.
+func overwrittenFiles(repo *git.Repository, hash plumbing.Hash, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var overwritten []string
+	for _, path := range paths {
+		_, err := tree.FindEntry(path)
+		if err == nil {
+			overwritten = append(overwritten, path)
+		}
+	}
+	sort.Strings(overwritten)
+	return overwritten, nil
+}
@@ -0,0 +1,135 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+
+	"github.com/aws-controllers-k8s/dev-tools/pkg/testutil"
+
+	"github.com/aws-controllers-k8s/dev-tools/mocks"
+)
+
+// newTestSyncRepository returns an in-memory repository on the main branch,
+// with an upstream/main reference pointing to a commit that is
+// behindUpstream commits ahead of main.
+func newTestSyncRepository(t *testing.T, behindUpstream int) *git.Repository {
+	require := require.New(t)
+
+	repo, err := testutil.NewInMemoryGitRepository()
+	require.NoError(err)
+	require.NoError(testutil.CheckoutBranch(repo, "main"))
+
+	head, err := repo.Head()
+	require.NoError(err)
+
+	upstreamHash := head.Hash()
+	for i := 0; i < behindUpstream; i++ {
+		upstreamHash, err = testutil.CommitFile(repo, "upstream.txt", string(rune('a'+i)), "upstream commit")
+		require.NoError(err)
+	}
+	require.NoError(repo.Storer.SetReference(plumbing.NewHashReference(
+		plumbing.NewRemoteReferenceName("upstream", "main"),
+		upstreamHash,
+	)))
+
+	// move main back to its original commit
+	w, err := repo.Worktree()
+	require.NoError(err)
+	require.NoError(w.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.HardReset}))
+	return repo
+}
+
+func TestManager_Sync(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	behindRepo := newTestSyncRepository(t, 2)
+	upToDateRepo := newTestSyncRepository(t, 0)
+
+	aheadRepo := newTestSyncRepository(t, 0)
+	_, err := testutil.CommitFile(aheadRepo, "local.txt", "local", "local commit")
+	require.NoError(err)
+
+	dirtyRepo := newTestSyncRepository(t, 1)
+	require.NoError(testutil.WriteFile(dirtyRepo, "ramanujan_serie.txt", "1 + 1 = 2"))
+
+	// upstream adds upstream.txt
+	untrackedRepo := newTestSyncRepository(t, 1)
+	require.NoError(testutil.WriteFile(untrackedRepo, "upstream.txt", "local"))
+	require.NoError(testutil.WriteFile(untrackedRepo, "notes.txt", "local"))
+
+	featureRepo := newTestSyncRepository(t, 1)
+	require.NoError(testutil.CheckoutBranch(featureRepo, "feature-xyz"))
+
+	fakeGit := &mocks.Client{}
+	fakeGit.On("Fetch", testingCtx, mock.Anything, "upstream").Return(nil)
+	fakeGit.On("Push", testingCtx, mock.Anything, "origin", "refs/heads/main:refs/heads/main").Return(nil)
+
+	m := &Manager{
+		cfg: testutil.NewConfig(),
+		git: fakeGit,
+	}
+
+	repos := []*Repository{
+		{Name: "behind", gitRepo: behindRepo},
+		{Name: "up-to-date", gitRepo: upToDateRepo},
+		{Name: "ahead", gitRepo: aheadRepo},
+		{Name: "dirty", gitRepo: dirtyRepo},
+		{Name: "feature", gitRepo: featureRepo},
+		{Name: "not-cloned"},
+		{Name: "untracked", gitRepo: untrackedRepo},
+	}
+	results := m.Sync(testingCtx, 3, repos...)
+	require.Len(results, len(repos))
+
+	for _, res := range results {
+		assert.NoError(res.Err, res.Repository.Name)
+	}
+
+	assert.Equal(SyncStatusSynced, results[0].Status)
+	assert.Equal(2, results[0].Behind)
+	assert.Equal(0, results[0].Ahead)
+	head, err := behindRepo.Head()
+	require.NoError(err)
+	upstreamRef, err := behindRepo.Reference(plumbing.NewRemoteReferenceName("upstream", "main"), true)
+	require.NoError(err)
+	assert.Equal(upstreamRef.Hash(), head.Hash())
+	assert.Equal(plumbing.NewBranchReferenceName("main"), head.Name())
+
+	assert.Equal(SyncStatusUpToDate, results[1].Status)
+
+	assert.Equal(SyncStatusSkipped, results[2].Status)
+	assert.Equal(1, results[2].Ahead)
+
+	assert.Equal(SyncStatusSkipped, results[3].Status)
+	assert.Equal("worktree has uncommitted changes", results[3].Reason)
+
+	assert.Equal(SyncStatusSkipped, results[4].Status)
+	assert.Equal("current branch is feature-xyz", results[4].Reason)
+
+	assert.Equal(SyncStatusSkipped, results[5].Status)
+
+	assert.Equal(SyncStatusSkipped, results[6].Status)
+	assert.Equal("untracked files would be overwritten: upstream.txt", results[6].Reason)
+
+	// only the repositories that were fast-forwarded or up to date are pushed
+	fakeGit.AssertNumberOfCalls(t, "Push", 2)
+}
@@ -13,6 +13,8 @@
 
 package repository
 
+import "fmt"
+
 type RepositoryType int
 
 const (
@@ -29,6 +31,8 @@ func (rt RepositoryType) String() string {
 		return "core"
 	case RepositoryTypeController:
 		return "controller"
+	case RepositoryTypeTooling:
+		return "tooling"
 	case RepositoryTypeUnknown:
 		return "UNKNOWN"
 	default:
@@ -36,14 +40,75 @@ func (rt RepositoryType) String() string {
 	}
 }
 
-// repositoryTypeFromString casts a string to a RepositoryType
-func repositoryTypeFromString(s string) RepositoryType {
+// MarshalText implements encoding.TextMarshaler, encoding repository types
+// as strings.
+func (rt RepositoryType) MarshalText() ([]byte, error) {
+	return []byte(rt.String()), nil
+}
+
+// repositoryTypeFromString casts a string to a RepositoryType. It returns
+// RepositoryTypeUnknown and an error if the string isn't a known type.
+func repositoryTypeFromString(s string) (RepositoryType, error) {
 	switch s {
 	case "core":
-		return RepositoryTypeCore
+		return RepositoryTypeCore, nil
 	case "controller":
-		return RepositoryTypeController
+		return RepositoryTypeController, nil
+	case "tooling":
+		return RepositoryTypeTooling, nil
 	default:
-		panic("unsupported repository type")
+		return RepositoryTypeUnknown, fmt.Errorf("unsupported repository type: %s", s)
+	}
+}
+
+// RepositoryState describes the state of a local repository.
+type RepositoryState int
+
+const (
+	// RepositoryStateNotCloned means that the repository doesn't exist locally
+	RepositoryStateNotCloned RepositoryState = iota
+	// RepositoryStateEmpty means that the repository exists but has no commits
+	RepositoryStateEmpty
+	// RepositoryStateDetached means that HEAD points directly to a commit
+	RepositoryStateDetached
+	// RepositoryStateBranch means that HEAD points to a branch
+	RepositoryStateBranch
+)
+
+// String stringifies a Repository state
+func (rs RepositoryState) String() string {
+	switch rs {
+	case RepositoryStateNotCloned:
+		return "not-cloned"
+	case RepositoryStateEmpty:
+		return "empty"
+	case RepositoryStateDetached:
+		return "detached"
+	case RepositoryStateBranch:
+		return "branch"
+	default:
+		panic("unsupported repository state")
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding repository states
+// as strings.
+func (rs RepositoryState) MarshalText() ([]byte, error) {
+	return []byte(rs.String()), nil
+}
+
+// repositoryStateFromString casts a string to a RepositoryState.
+func repositoryStateFromString(s string) (RepositoryState, error) {
+	switch s {
+	case "not-cloned":
+		return RepositoryStateNotCloned, nil
+	case "empty":
+		return RepositoryStateEmpty, nil
+	case "detached":
+		return RepositoryStateDetached, nil
+	case "branch":
+		return RepositoryStateBranch, nil
+	default:
+		return RepositoryStateNotCloned, fmt.Errorf("unsupported repository state: %s", s)
 	}
 }
@@ -16,6 +16,7 @@ package testutil
 import (
 	"gopkg.in/src-d/go-billy.v4/memfs"
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 	"gopkg.in/src-d/go-git.v4/storage/memory"
 )
@@ -66,3 +67,58 @@ func NewInMemoryGitRepository() (*git.Repository, error) {
 	}
 	return repo, nil
 }
+
+// WriteFile writes a file in the worktree of an in-memory git repository
+// without committing it.
+func WriteFile(repo *git.Repository, name, content string) error {
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	file, err := w.Filesystem.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write([]byte(content))
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// CommitFile writes a file in the worktree of an in-memory git repository
+// and commits it on the current branch. It returns the new commit hash.
+func CommitFile(repo *git.Repository, name, content, message string) (plumbing.Hash, error) {
+	err := WriteFile(repo, name, content)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	_, err = w.Add(name)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return w.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Srinivasa Ramanujan",
+			Email: "sramanujan@1729",
+		},
+	})
+}
+
+// CheckoutBranch creates a new branch pointing to the current HEAD of an
+// in-memory git repository and checks it out.
+func CheckoutBranch(repo *git.Repository, branch string) error {
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return w.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(branch),
+		Create: true,
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelize(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     int
+		n           int
+		wantWorkers int
+	}{
+		{"no jobs", 4, 0, 0},
+		{"single worker", 1, 5, 1},
+		{"bounded by workers", 2, 6, 2},
+		{"bounded by jobs", 8, 3, 3},
+		{"zero workers", 0, 3, 1},
+		{"negative workers", -1, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := make([]int, tt.n)
+			running, maxRunning := 0, 0
+
+			Parallelize(tt.workers, tt.n, func(i int) {
+				mu.Lock()
+				calls[i]++
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+			})
+
+			for i, c := range calls {
+				assert.Equal(t, 1, c, "index %d", i)
+			}
+			assert.Equal(t, tt.wantWorkers, maxRunning)
+		})
+	}
+}
@@ -18,13 +18,15 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
+	"sync"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
-// newSigner returns a ssh.Signer from a PEM encoded private key path.
+// NewSigner returns a ssh.Signer from a PEM encoded private key path.
 // If the PEM file is encrypted it will try to read the passphrase from
 // a terminal without local echo.
 func NewSigner(sshKeyPath string) (ssh.Signer, error) {
@@ -38,16 +40,39 @@ func NewSigner(sshKeyPath string) (ssh.Signer, error) {
 		return nil, errors.New("invalid ssh certificate")
 	}
 
-	if encryptedBlock(block) {
-		passphrase, err := promptPassphrase()
-		if err != nil {
-			return nil, err
+	if !encryptedBlock(block) {
+		signer, err := ssh.ParsePrivateKey(pemBytes)
+		// OpenSSH formatted keys don't have a Proc-Type header, we only know
+		// they are encrypted after trying to parse them.
+		if _, ok := err.(*ssh.PassphraseMissingError); !ok {
+			return signer, err
 		}
+	}
 
-		return ssh.ParsePrivateKeyWithPassphrase(pemBytes, passphrase)
+	passphrase, err := promptPassphrase()
+	if err != nil {
+		return nil, err
 	}
+	return ssh.ParsePrivateKeyWithPassphrase(pemBytes, passphrase)
+}
 
-	return ssh.ParsePrivateKey(pemBytes)
+// NewSignerOnce returns a function loading the ssh.Signer of the given
+// private key path. The key is only loaded the first time the function
+// is called, next calls return the same signer or error. This guarantees
+// that the passphrase of an encrypted key is only prompted once.
+func NewSignerOnce(sshKeyPath string) func() (ssh.Signer, error) {
+	var once sync.Once
+	var signer ssh.Signer
+	var err error
+	return func() (ssh.Signer, error) {
+		once.Do(func() {
+			signer, err = NewSigner(sshKeyPath)
+			if err != nil {
+				err = fmt.Errorf("cannot load ssh key %s: %v", sshKeyPath, err)
+			}
+		})
+		return signer, err
+	}
 }
 
 // encryptedBlock tells whether a private key is
@@ -60,7 +85,9 @@ func encryptedBlock(block *pem.Block) bool {
 
 func promptPassphrase() ([]byte, error) {
 	fmt.Printf("type your ssh key passphrase:")
-	passphrase, err := terminal.ReadPassword(0)
+	passphrase, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	// terminal.ReadPassword doesn't echo the new line
+	fmt.Println()
 	if err != nil {
 		return nil, err
 	}
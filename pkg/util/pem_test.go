@@ -0,0 +1,80 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package util
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKey(t *testing.T, dir string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	keyPath := filepath.Join(dir, "id_rsa")
+	require.NoError(t, ioutil.WriteFile(keyPath, pemBytes, 0600))
+	return keyPath
+}
+
+func TestNewSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-pem")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyPath := writeTestKey(t, dir)
+	signer, err := NewSigner(keyPath)
+	require.NoError(t, err)
+	assert.NotNil(t, signer)
+
+	invalidKeyPath := filepath.Join(dir, "invalid")
+	require.NoError(t, ioutil.WriteFile(invalidKeyPath, []byte("not a key"), 0600))
+	_, err = NewSigner(invalidKeyPath)
+	assert.Error(t, err)
+
+	_, err = NewSigner(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestNewSignerOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ackdev-pem")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyPath := writeTestKey(t, dir)
+	signerFunc := NewSignerOnce(keyPath)
+	first, err := signerFunc()
+	require.NoError(t, err)
+
+	// the key is not read again once loaded
+	require.NoError(t, os.Remove(keyPath))
+	second, err := signerFunc()
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	_, err = NewSignerOnce(keyPath)()
+	assert.Error(t, err)
+}